@@ -0,0 +1,259 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	db "github.com/lundjrl/go-bubble-tea-playground/shared/database"
+)
+
+// backupTimeLayout timestamps backup names so they sort oldest first. The
+// milliseconds keep a backup from replacing one made the same second.
+const backupTimeLayout = "20060102-150405.000"
+
+// backupStore is a store kept in a SQLite file, which is what can be backed
+// up and restored.
+type backupStore interface {
+	db.InventoryStore
+	BackupTo(dest string) error
+	RestoreFrom(src string) error
+}
+
+func asBackupStore(store db.InventoryStore) (backupStore, error) {
+	backups, ok := store.(backupStore)
+	if !ok || store.Path() == "" {
+		return nil, errors.New("Only a database file can be backed up.")
+	}
+	return backups, nil
+}
+
+// backupDir is the backup_dir setting, or a backups folder next to the
+// database.
+func backupDir(store db.InventoryStore, settings appSettings) string {
+	if settings.backupDir != "" {
+		return settings.backupDir
+	}
+	return filepath.Join(filepath.Dir(store.Path()), "backups")
+}
+
+// listBackups returns the timestamped backups in dir, oldest first.
+func listBackups(dir string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "chef-*.db"))
+	if err != nil {
+		return nil, err
+	}
+
+	backups := []string{}
+	for _, path := range matches {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), "chef-"), ".db")
+		if _, err := time.Parse(backupTimeLayout, stamp); err == nil {
+			backups = append(backups, path)
+		}
+	}
+
+	sort.Strings(backups)
+
+	return backups, nil
+}
+
+// rotateBackups deletes all but the newest keep backups in dir.
+func rotateBackups(dir string, keep int) error {
+	backups, err := listBackups(dir)
+	if err != nil {
+		return err
+	}
+
+	for len(backups) > max(keep, 1) {
+		if err := os.Remove(backups[0]); err != nil {
+			return err
+		}
+		backups = backups[1:]
+	}
+
+	return nil
+}
+
+// writeBackup writes a timestamped backup to dir.
+func writeBackup(store backupStore, dir string) (string, error) {
+	dest := filepath.Join(dir, "chef-"+time.Now().Format(backupTimeLayout)+".db")
+	return dest, store.BackupTo(dest)
+}
+
+// backupNow writes a timestamped backup to the backup directory and rotates
+// out the old ones.
+func backupNow(store backupStore, settings appSettings) (string, error) {
+	dir := backupDir(store, settings)
+
+	dest, err := writeBackup(store, dir)
+	if err != nil {
+		return "", err
+	}
+
+	return dest, rotateBackups(dir, settings.backupKeep)
+}
+
+// autoBackup runs the backup on TUI start when it's turned on. A failed
+// backup is worth a warning but shouldn't keep chef from opening.
+func autoBackup(store db.InventoryStore) {
+	values, err := store.GetSettings()
+	if err != nil {
+		log.Warn("Couldn't read settings for the automatic backup.", "err", err)
+		return
+	}
+
+	settings := loadSettings(values)
+	if !settings.autoBackup {
+		return
+	}
+
+	backups, err := asBackupStore(store)
+	if err != nil {
+		return
+	}
+
+	if _, err := backupNow(backups, settings); err != nil {
+		log.Warn("The automatic backup failed.", "err", err)
+	}
+}
+
+// backupSettings loads the settings, applying and remembering --dir and
+// --keep when they're given.
+func backupSettings(store db.InventoryStore, dir string, keep int) (appSettings, error) {
+	values, err := store.GetSettings()
+	if err != nil {
+		return appSettings{}, err
+	}
+	settings := loadSettings(values)
+
+	if dir != "" {
+		if dir, err = filepath.Abs(dir); err != nil {
+			return settings, err
+		}
+		if err := store.SetSetting(db.SettingBackupDir, dir); err != nil {
+			return settings, err
+		}
+		settings.backupDir = dir
+	}
+
+	if keep < 0 {
+		return settings, usagef("--keep must be at least 1.")
+	}
+	if keep > 0 {
+		if err := store.SetSetting(db.SettingBackupKeep, strconv.Itoa(keep)); err != nil {
+			return settings, err
+		}
+		settings.backupKeep = keep
+	}
+
+	return settings, nil
+}
+
+func backupCommand(fs *flag.FlagSet) func(db.InventoryStore, []string) error {
+	dir := fs.String("dir", "", "directory to keep backups in, remembered for next time")
+	keep := fs.Int("keep", 0, "how many backups to keep, remembered for next time (default 7)")
+	list := fs.Bool("list", false, "list the backups instead of making one")
+
+	return func(store db.InventoryStore, args []string) error {
+		if len(args) > 0 {
+			return usagef("backup doesn't take arguments, use --dir to choose where backups go.")
+		}
+
+		backups, err := asBackupStore(store)
+		if err != nil {
+			return err
+		}
+
+		settings, err := backupSettings(store, *dir, *keep)
+		if err != nil {
+			return err
+		}
+
+		if *list {
+			paths, err := listBackups(backupDir(store, settings))
+			if err != nil {
+				return err
+			}
+			if len(paths) == 0 {
+				fmt.Fprintf(os.Stderr, "There are no backups in %s yet.\n", backupDir(store, settings))
+			}
+			for _, path := range paths {
+				fmt.Println(path)
+			}
+			return nil
+		}
+
+		dest, err := backupNow(backups, settings)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Backed up to %s.\n", dest)
+
+		return nil
+	}
+}
+
+func restoreCommand(fs *flag.FlagSet) func(db.InventoryStore, []string) error {
+	dir := fs.String("dir", "", "directory to look for backups in (default the backup directory)")
+
+	return func(store db.InventoryStore, args []string) error {
+		if len(args) > 1 {
+			return usagef("Please give one backup to restore, or none for the newest.")
+		}
+
+		backups, err := asBackupStore(store)
+		if err != nil {
+			return err
+		}
+
+		values, err := store.GetSettings()
+		if err != nil {
+			return err
+		}
+		settings := loadSettings(values)
+		if *dir != "" {
+			settings.backupDir = *dir
+		}
+		from := backupDir(store, settings)
+
+		var src string
+		if len(args) == 1 {
+			src = args[0]
+		} else {
+			paths, err := listBackups(from)
+			if err != nil {
+				return err
+			}
+			if len(paths) == 0 {
+				return fmt.Errorf("There are no backups in %s.", from)
+			}
+			src = paths[len(paths)-1]
+		}
+
+		// Back up what's about to be overwritten, in case it was the wrong
+		// backup. Rotating only afterwards keeps src around until it's read.
+		safety, err := writeBackup(backups, backupDir(store, loadSettings(values)))
+		if err != nil {
+			return err
+		}
+
+		// A failed restore leaves the database untouched, so the copy isn't
+		// needed.
+		if err := backups.RestoreFrom(src); err != nil {
+			os.Remove(safety)
+			return err
+		}
+
+		fmt.Printf("Restored %s. The database as it was before is in %s.\n", src, safety)
+
+		return rotateBackups(filepath.Dir(safety), settings.backupKeep)
+	}
+}
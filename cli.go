@@ -0,0 +1,600 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	db "github.com/lundjrl/go-bubble-tea-playground/shared/database"
+	"github.com/lundjrl/go-bubble-tea-playground/shared/ingredient"
+	"github.com/lundjrl/go-bubble-tea-playground/shared/units"
+)
+
+// Exit codes, so scripts can tell a typo from a real failure.
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+// usageError is returned when a command was called the wrong way.
+type usageError struct {
+	err error
+}
+
+func (e usageError) Error() string {
+	return e.err.Error()
+}
+
+func usagef(format string, args ...any) error {
+	return usageError{fmt.Errorf(format, args...)}
+}
+
+// command is a subcommand such as `chef add`. Most commands work on the
+// store and declare their flags in flags. The few that open the database
+// themselves, like migrate, set open instead.
+type command struct {
+	name     string
+	args     string
+	about    string
+	examples []string
+	flags    func(fs *flag.FlagSet) func(store db.InventoryStore, args []string) error
+	open     func(path string, args []string) error
+}
+
+// commands is filled in by init, since help refers back to it.
+var commands []command
+
+func init() {
+	commands = []command{
+		{
+			name: "init", about: "open the app",
+			examples: []string{"chef init", "chef --db ~/pantry.db init"},
+			open:     initCommand,
+		},
+		{
+			name: "add", args: "<item>", about: "add an item, or restock it if it's already there",
+			examples: []string{"chef add milk --count 2 --unit gallon", "chef add \"2 lb ground beef\" --location Freezer --expires 2026-01-31"},
+			flags:    addCommand,
+		},
+		{
+			name: "use", args: "<item> [amount]", about: "take an amount of an item out of the inventory",
+			examples: []string{"chef use eggs 3", "chef use milk 2 cups"},
+			flags:    useCommand,
+		},
+		{
+			name: "set", args: "<item> <amount>", about: "set how much of an item there is",
+			examples: []string{"chef set flour 500g"},
+			flags:    setCommand,
+		},
+		{
+			name: "remove", args: "<item>", about: "move an item to the trash",
+			examples: []string{"chef remove bread"},
+			flags:    removeCommand,
+		},
+		{
+			name: "category", args: "list | add <name>", about: "print the categories, or add one",
+			examples: []string{"chef category list", "chef category add snacks"},
+			flags:    categoryCommand,
+		},
+		{
+			name: "list", about: "print the inventory",
+			examples: []string{"chef list --low", "chef list --location Fridge --format csv"},
+			flags:    listCommand,
+		},
+		{
+			name: "show", args: "<item>", about: "print one item and its history",
+			examples: []string{"chef show milk", "chef show milk --format json"},
+			flags:    showCommand,
+		},
+		{
+			name: "import", args: "<backup.json> | csv <file>", about: "bring in a backup from chef export, or items from a spreadsheet",
+			examples: []string{"chef import backup.json", "chef import --replace backup.json", "chef import csv pantry.csv --dry-run"},
+			flags:    importCommand,
+		},
+		{
+			name: "export", about: "write everything to a JSON backup",
+			examples: []string{"chef export --out backup.json"},
+			flags:    exportCommand,
+		},
+		{
+			name: "backup", about: "copy the database to the backup directory",
+			examples: []string{"chef backup", "chef backup --dir ~/Dropbox/chef --keep 14", "chef backup --list"},
+			flags:    backupCommand,
+		},
+		{
+			name: "restore", args: "[backup.db]", about: "put a backup back, the newest one by default",
+			examples: []string{"chef restore", "chef restore ~/.local/share/chef/backups/chef-20260101-090000.000.db"},
+			flags:    restoreCommand,
+		},
+		{
+			name: "migrate", args: "status|up|down [--yes]", about: "inspect or step through database migrations",
+			examples: []string{"chef migrate status", "chef migrate down --yes"},
+			open:     migrateCommand,
+		},
+		{
+			name: "help", args: "[command]", about: "show this help, or a command's flags and examples",
+			examples: []string{"chef help add"},
+			open:     helpCommand,
+		},
+	}
+}
+
+func findCommand(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+// parseInterspersed parses flags wherever they appear among the positional
+// arguments, so `chef add milk --count 2` works as well as
+// `chef add --count 2 milk`. Everything after "--" is positional.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional := []string{}
+
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// commandFlags returns c's flag set, with the flags declared.
+func commandFlags(c command) (*flag.FlagSet, func(db.InventoryStore, []string) error) {
+	fs := flag.NewFlagSet("chef "+c.name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	if c.flags == nil {
+		return fs, nil
+	}
+	return fs, c.flags(fs)
+}
+
+func isHelpFlag(arg string) bool {
+	return arg == "-h" || arg == "-help" || arg == "--help"
+}
+
+// dispatch runs c with the database at path.
+func dispatch(c command, path string, args []string) error {
+	if c.open != nil {
+		if len(args) == 1 && isHelpFlag(args[0]) {
+			fs, _ := commandFlags(c)
+			printCommandUsage(os.Stdout, c, fs)
+			return nil
+		}
+		return c.open(path, args)
+	}
+
+	fs, run := commandFlags(c)
+
+	positional, err := parseInterspersed(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		printCommandUsage(os.Stdout, c, fs)
+		return nil
+	}
+	if err != nil {
+		printCommandUsage(os.Stderr, c, fs)
+		return usageError{err}
+	}
+
+	store, err := db.OpenGormStore(path)
+	if err != nil {
+		return err
+	}
+
+	return run(store, positional)
+}
+
+func initCommand(path string, args []string) error {
+	if len(args) > 0 {
+		return usagef("init doesn't take arguments.")
+	}
+
+	store, err := db.OpenGormStore(path)
+	if err != nil {
+		return err
+	}
+
+	autoBackup(store)
+
+	return runTUI(store)
+}
+
+func findCategory(store db.InventoryStore, name string) (*uint, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	categories, err := store.GetCategories()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, category := range categories {
+		if strings.EqualFold(category.Name, strings.TrimSpace(name)) {
+			return &category.ID, nil
+		}
+	}
+
+	return nil, fmt.Errorf("There's no category called %s.", name)
+}
+
+// findLocation matches a location by its full path ("House > Kitchen") or,
+// when that's unambiguous, by its name alone.
+func findLocation(store db.InventoryStore, name string) (*uint, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	locations, err := store.GetLocations()
+	if err != nil {
+		return nil, err
+	}
+
+	name = strings.Join(strings.Fields(name), " ")
+	matches := []db.Location{}
+
+	for _, location := range locations {
+		if strings.EqualFold(db.LocationPath(locations, location.ID), name) {
+			return &location.ID, nil
+		}
+		if strings.EqualFold(location.Name, name) {
+			matches = append(matches, location)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("There's no location called %s.", name)
+	case 1:
+		return &matches[0].ID, nil
+	}
+
+	return nil, fmt.Errorf("There are several locations called %s. Use the full path, like %s.", name, db.LocationPath(locations, matches[0].ID))
+}
+
+// itemName joins the positional arguments, so quoting the name is optional.
+func itemName(args []string, what string) (string, error) {
+	if len(args) == 0 {
+		return "", usagef("Please say which %s.", what)
+	}
+	return strings.Join(args, " "), nil
+}
+
+func addCommand(fs *flag.FlagSet) func(db.InventoryStore, []string) error {
+	count := fs.Float64("count", 0, "how many to add (default from settings)")
+	unit := fs.String("unit", "", "unit the count is in, e.g. gallon or g")
+	category := fs.String("category", "", "category to file the item under")
+	location := fs.String("location", "", "where the item is kept, e.g. \"Kitchen > Fridge\"")
+	expires := fs.String("expires", "", "expiry date as YYYY-MM-DD")
+	minCount := fs.Float64("min", 0, "keep at least this many, topping up the grocery list")
+
+	return func(store db.InventoryStore, args []string) error {
+		text, err := itemName(args, "item to add")
+		if err != nil {
+			return err
+		}
+
+		// The name can carry its own amount, as in `chef add "2 gallons milk"`.
+		line, err := ingredient.Parse(text)
+		if err != nil {
+			return err
+		}
+
+		if *count < 0 || *minCount < 0 {
+			return usagef("Counts can't be negative.")
+		}
+		if *count > 0 {
+			line.Quantity = *count
+		}
+		if *unit != "" {
+			line.Unit = *unit
+		}
+		if line.Quantity == 0 {
+			values, err := store.GetSettings()
+			if err != nil {
+				return err
+			}
+			line.Quantity = float64(loadSettings(values).defaultCount)
+		}
+
+		draft := db.GroceryItem{Name: line.Name, Count: line.Quantity, Unit: line.Unit, MinCount: *minCount}
+
+		if draft.CategoryID, err = findCategory(store, *category); err != nil {
+			return err
+		}
+		if draft.LocationID, err = findLocation(store, *location); err != nil {
+			return err
+		}
+		if draft.ExpiresAt, err = parseDate(*expires); err != nil {
+			return usageError{err}
+		}
+
+		item, created, err := store.UpsertGroceryItem(draft)
+		if err != nil {
+			return err
+		}
+
+		if created {
+			fmt.Printf("Added %s %s.\n", formatQuantity(item.Count, item.Unit), item.Name)
+		} else {
+			fmt.Printf("Restocked %s, now %s.\n", item.Name, formatQuantity(item.Count, item.Unit))
+		}
+
+		return nil
+	}
+}
+
+// splitAmount separates an item name from the amount that follows it. The
+// amount may be one or two arguments, so `chef use eggs 3` and
+// `chef use milk 2 cups` both work. found is false when there's no amount.
+func splitAmount(args []string) (name string, amount string, found bool) {
+	for n := min(2, len(args)-1); n > 0; n-- {
+		tail := strings.Join(args[len(args)-n:], " ")
+		if _, _, err := ingredient.ParseAmount(tail); err == nil {
+			return strings.Join(args[:len(args)-n], " "), tail, true
+		}
+	}
+
+	return strings.Join(args, " "), "", false
+}
+
+// bareUnit is the unit a number typed without one is read in. Counted items
+// take single ones, so `chef use eggs 3` works on eggs kept by the dozen.
+// Anything else is read in the item's own unit.
+func bareUnit(item db.GroceryItem) string {
+	if unit, ok := units.Lookup(item.Unit); ok && unit.Dimension == units.Count {
+		return "each"
+	}
+	return item.Unit
+}
+
+// amountIn converts an amount typed on the command line into item's unit.
+func amountIn(item db.GroceryItem, text string) (float64, error) {
+	quantity, unit, err := ingredient.ParseAmount(text)
+	if err != nil {
+		return 0, usageError{err}
+	}
+
+	if unit == "" {
+		unit = bareUnit(item)
+	}
+
+	return units.Convert(quantity, unit, item.Unit)
+}
+
+func useCommand(fs *flag.FlagSet) func(db.InventoryStore, []string) error {
+	return func(store db.InventoryStore, args []string) error {
+		if len(args) == 0 {
+			return usagef("Please say which item was used.")
+		}
+
+		name, amount, found := splitAmount(args)
+		if !found {
+			amount = "1"
+		}
+
+		item, err := store.GetGroceryItemByName(name)
+		if err != nil {
+			return err
+		}
+
+		quantity, err := amountIn(item, amount)
+		if err != nil {
+			return err
+		}
+
+		if quantity > item.Count {
+			return fmt.Errorf("There's only %s of %s.", formatQuantity(item.Count, item.Unit), item.Name)
+		}
+
+		item, err = store.AdjustGroceryItemCount(item.ID, -quantity)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Used %s %s, %s left.\n", formatQuantity(quantity, item.Unit), item.Name, formatQuantity(item.Count, item.Unit))
+
+		return nil
+	}
+}
+
+func setCommand(fs *flag.FlagSet) func(db.InventoryStore, []string) error {
+	return func(store db.InventoryStore, args []string) error {
+		if len(args) < 2 {
+			return usagef("Please give an item and an amount, like `chef set flour 500g`.")
+		}
+
+		name, amount, found := splitAmount(args)
+		if !found {
+			return usagef("%q isn't an amount. Try something like 3 or 500g.", args[len(args)-1])
+		}
+
+		item, err := store.GetGroceryItemByName(name)
+		if err != nil {
+			return err
+		}
+
+		quantity, unit, err := ingredient.ParseAmount(amount)
+		if err != nil {
+			return usageError{err}
+		}
+
+		// Keep the item's unit when the amount has none or can be converted
+		// into it.
+		if unit == "" {
+			unit = bareUnit(item)
+		}
+		if converted, err := units.Convert(quantity, unit, item.Unit); err == nil {
+			item.Count = converted
+		} else {
+			item.Count, item.Unit = quantity, unit
+		}
+
+		item, err = store.UpdateGroceryItem(item)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s is now %s.\n", item.Name, formatQuantity(item.Count, item.Unit))
+
+		return nil
+	}
+}
+
+func removeCommand(fs *flag.FlagSet) func(db.InventoryStore, []string) error {
+	return func(store db.InventoryStore, args []string) error {
+		name, err := itemName(args, "item to remove")
+		if err != nil {
+			return err
+		}
+
+		item, err := store.GetGroceryItemByName(name)
+		if err != nil {
+			return err
+		}
+
+		if err := store.DeleteGroceryItemByID(item.ID); err != nil {
+			return err
+		}
+
+		fmt.Printf("Moved %s to the trash.\n", item.Name)
+
+		return nil
+	}
+}
+
+func categoryCommand(fs *flag.FlagSet) func(db.InventoryStore, []string) error {
+	return func(store db.InventoryStore, args []string) error {
+		switch {
+		case len(args) == 1 && args[0] == "list":
+			categories, err := store.GetCategories()
+			if err != nil {
+				return err
+			}
+			for _, category := range categories {
+				fmt.Println(category.Name)
+			}
+			return nil
+		case len(args) > 1 && args[0] == "add":
+			category, err := store.CreateCategory(strings.Join(args[1:], " "))
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Added the %s category.\n", category.Name)
+			return nil
+		}
+
+		return usagef("Please say list or add, like `chef category add snacks`.")
+	}
+}
+
+func listCommand(fs *flag.FlagSet) func(db.InventoryStore, []string) error {
+	category := fs.String("category", "", "only show items in this category")
+	location := fs.String("location", "", "only show items kept here, including places inside it")
+	low := fs.Bool("low", false, "only show items below their minimum count")
+	formatText := fs.String("format", "table", "output as table, json, csv or tsv")
+
+	return func(store db.InventoryStore, args []string) error {
+		if len(args) > 0 {
+			return usagef("list doesn't take arguments, got %q.", strings.Join(args, " "))
+		}
+
+		format, err := parseFormat(*formatText)
+		if err != nil {
+			return err
+		}
+
+		categoryID, err := findCategory(store, *category)
+		if err != nil {
+			return err
+		}
+		locationID, err := findLocation(store, *location)
+		if err != nil {
+			return err
+		}
+
+		items, err := store.GetGroceryItems()
+		if err != nil {
+			return err
+		}
+		locations, err := store.GetLocations()
+		if err != nil {
+			return err
+		}
+
+		var inLocation map[uint]bool
+		if locationID != nil {
+			inLocation = db.LocationSubtree(locations, *locationID)
+		}
+
+		records := []itemRecord{}
+
+		for _, item := range items {
+			if categoryID != nil && (item.CategoryID == nil || *item.CategoryID != *categoryID) {
+				continue
+			}
+			if inLocation != nil && (item.LocationID == nil || !inLocation[*item.LocationID]) {
+				continue
+			}
+			if *low && !lowStock(item) {
+				continue
+			}
+
+			records = append(records, newItemRecord(item, locations))
+		}
+
+		return writeItems(os.Stdout, format, records)
+	}
+}
+
+func showCommand(fs *flag.FlagSet) func(db.InventoryStore, []string) error {
+	formatText := fs.String("format", "table", "output as table, json, csv or tsv")
+
+	return func(store db.InventoryStore, args []string) error {
+		format, err := parseFormat(*formatText)
+		if err != nil {
+			return err
+		}
+
+		name, err := itemName(args, "item to show")
+		if err != nil {
+			return err
+		}
+
+		item, err := store.GetGroceryItemByName(name)
+		if err != nil {
+			return err
+		}
+
+		locations, err := store.GetLocations()
+		if err != nil {
+			return err
+		}
+
+		record := newItemRecord(item, locations)
+		if record.History, err = store.GetInventoryEvents(item.ID); err != nil {
+			return err
+		}
+
+		return writeItemDetail(os.Stdout, format, record)
+	}
+}
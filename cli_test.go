@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+
+	db "github.com/lundjrl/go-bubble-tea-playground/shared/database"
+)
+
+func TestAmountIn(t *testing.T) {
+	tests := []struct {
+		unit string
+		text string
+		want float64
+	}{
+		{"", "3", 3},
+		{"each", "2 pairs", 4},
+		{"dozen", "3", 0.25},
+		{"dozen", "1 dozen", 1},
+		{"g", "500", 500},
+		{"g", "1kg", 1000},
+		{"l", "500 ml", 0.5},
+		{"bag", "2", 2},
+	}
+
+	for _, test := range tests {
+		item := db.GroceryItem{Name: "item", Unit: test.unit}
+		got, err := amountIn(item, test.text)
+		if err != nil {
+			t.Errorf("amountIn(%q, %q): %v", test.unit, test.text, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("amountIn(%q, %q) = %v, want %v", test.unit, test.text, got, test.want)
+		}
+	}
+
+	if _, err := amountIn(db.GroceryItem{Unit: "g"}, "2 l"); err == nil {
+		t.Error("amountIn(g, 2 l) succeeded, want an error")
+	}
+}
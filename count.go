@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// updateCount handles "+" and "-" on the inventory table, optionally prefixed
+// with a number ("3+"). It reports whether the key was consumed.
+func updateCount(m mainModel, msg tea.KeyMsg) (mainModel, bool) {
+	key := msg.String()
+
+	switch key {
+	case "0", "1", "2", "3", "4", "5", "6", "7", "8", "9":
+		if len(m.countPrefix) < 4 && (m.countPrefix != "" || key != "0") {
+			m.countPrefix += key
+		}
+		return m, true
+	case "+", "-":
+	default:
+		m.countPrefix = ""
+		return m, false
+	}
+
+	delta := 1
+	if n, err := strconv.Atoi(m.countPrefix); err == nil {
+		delta = n
+	}
+	if key == "-" {
+		delta = -delta
+	}
+	m.countPrefix = ""
+
+	id, ok := selectedItemID(m)
+	if !ok {
+		return m, true
+	}
+
+	before, err := m.store.GetGroceryItemByID(id)
+	if err != nil {
+		m.err = err
+		return m, true
+	}
+
+	item, err := m.store.AdjustGroceryItemCount(id, float64(delta))
+	if err != nil {
+		m.err = err
+		return m, true
+	}
+
+	if item.Count != before.Count {
+		m = pushUndo(m, undoStep{
+			kind:        undoUpdate,
+			description: fmt.Sprintf("changing %s from %s to %s", item.Name, formatQuantity(before.Count, before.Unit), formatQuantity(item.Count, item.Unit)),
+			before:      before,
+			after:       item,
+		})
+	}
+
+	return reloadInventory(m, item.ID), true
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// openDelete asks for confirmation before deleting the highlighted row, or
+// deletes it straight away when confirmation is turned off in settings.
+func openDelete(m mainModel) mainModel {
+	row := m.table.SelectedRow()
+	if row == nil {
+		return m
+	}
+
+	if !m.settings.confirmDelete {
+		return deleteSelected(m)
+	}
+
+	m.state = confirmDeleteView
+	m.table.Blur()
+
+	return m
+}
+
+func deleteSelected(m mainModel) mainModel {
+	id, ok := selectedItemID(m)
+	if !ok {
+		return m
+	}
+
+	item, err := m.store.GetGroceryItemByID(id)
+	if err != nil {
+		m.err = err
+		return m
+	}
+
+	if err := m.store.DeleteGroceryItemByID(id); err != nil {
+		m.err = err
+		return m
+	}
+
+	m = pushUndo(m, undoStep{kind: undoDelete, description: "deleting " + item.Name, before: item})
+
+	return reloadInventory(m, 0)
+}
+
+func updateConfirmDelete(m mainModel, msg tea.KeyMsg) mainModel {
+	switch msg.String() {
+	case "y", "enter":
+		m = deleteSelected(m)
+	case "n", "esc":
+	default:
+		return m
+	}
+
+	m.state = tableView
+	m.table.Focus()
+
+	return m
+}
+
+func getConfirmDeleteUI(m mainModel) string {
+	name := ""
+	if row := m.table.SelectedRow(); row != nil {
+		name = row[1]
+	}
+
+	prompt := fmt.Sprintf("Delete %s?", name)
+
+	return detailStyle.Render(highlight.Bold(true).Render(prompt) + "\n\ny: yes • n: no")
+}
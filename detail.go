@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	detailStyle = lipgloss.NewStyle().
+			Width(49).
+			BorderStyle(lipgloss.RoundedBorder()).
+			BorderForeground(theme.pink).
+			Padding(0, 1).
+			MarginLeft(1).MarginTop(1)
+	detailLabelStyle = lipgloss.NewStyle().Foreground(theme.lavender).Width(10)
+)
+
+// openDetail loads the highlighted inventory row and shows it in the detail pane.
+func openDetail(m mainModel) mainModel {
+	id, ok := selectedItemID(m)
+	if !ok {
+		return m
+	}
+
+	item, err := m.store.GetGroceryItemByID(id)
+	if err != nil {
+		m.err = err
+		return m
+	}
+
+	events, err := m.store.GetInventoryEvents(id)
+	if err != nil {
+		m.err = err
+		return m
+	}
+
+	m.detail = item
+	m.detailEvents = events
+	m.state = detailView
+	m.table.Blur()
+
+	return m
+}
+
+func formatTime(t time.Time) string {
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+// historyLength is how many of an item's most recent events the detail pane shows.
+const historyLength = 6
+
+// formatDelta renders a change in count with its sign, e.g. "+500 ml".
+func formatDelta(delta float64, unit string) string {
+	if delta < 0 {
+		return "-" + formatQuantity(-delta, unit)
+	}
+	return "+" + formatQuantity(delta, unit)
+}
+
+func getHistoryUI(m mainModel) []string {
+	lines := []string{"", highlight.Render("History")}
+
+	if len(m.detailEvents) == 0 {
+		return append(lines, detailLabelStyle.Render("Nothing recorded yet."))
+	}
+
+	for i, event := range m.detailEvents {
+		if i == historyLength {
+			lines = append(lines, detailLabelStyle.Width(0).Render(fmt.Sprintf("and %d earlier", len(m.detailEvents)-historyLength)))
+			break
+		}
+		when := detailLabelStyle.Width(13).Render(event.CreatedAt.Local().Format("Jan 02 15:04"))
+		delta := ""
+		if event.Delta != 0 {
+			delta = formatDelta(event.Delta, event.Unit)
+		}
+		lines = append(lines, fmt.Sprintf("%s%-9s %s", when, delta, event.Note))
+	}
+
+	return lines
+}
+
+func getDetailUI(m mainModel) string {
+	item := m.detail
+
+	deleted := "-"
+	if item.DeletedAt.Valid {
+		deleted = formatTime(item.DeletedAt.Time)
+	}
+
+	expires := "-"
+	if item.ExpiresAt != nil {
+		expires = formatDate(item.ExpiresAt)
+	}
+
+	fields := [][2]string{
+		{"ID", fmt.Sprint(item.ID)},
+		{"Name", item.Name},
+		{"Count", formatQuantity(item.Count, item.Unit)},
+		{"Min count", formatQuantity(item.MinCount, item.Unit)},
+		{"Category", categoryLabel(m, categoryChoice(m, item.CategoryID), "-")},
+		{"Location", locationLabel(m, item.LocationID, "-")},
+		{"Expires", expires},
+		{"Created", formatTime(item.CreatedAt)},
+		{"Updated", formatTime(item.UpdatedAt)},
+		{"Deleted", deleted},
+	}
+
+	lines := []string{highlight.Bold(true).Render(item.Name), ""}
+	for _, field := range fields {
+		lines = append(lines, detailLabelStyle.Render(field[0])+field[1])
+	}
+	lines = append(lines, getHistoryUI(m)...)
+
+	return detailStyle.Render(strings.Join(lines, "\n"))
+}
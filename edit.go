@@ -0,0 +1,177 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	db "github.com/lundjrl/go-bubble-tea-playground/shared/database"
+)
+
+// Order of the inputs on the edit form.
+const (
+	editNameField = iota
+	editCountField
+	editUnitField
+	editMinCountField
+	editCategoryField
+	editLocationField
+	editExpiresField
+)
+
+var editLabels = []string{"Name", "Count", "Unit", "Min count", "Category", "Location", "Expires"}
+
+// openEdit fills the edit form with the highlighted inventory row.
+func openEdit(m mainModel) (mainModel, tea.Cmd) {
+	id, ok := selectedItemID(m)
+	if !ok {
+		return m, nil
+	}
+
+	item, err := m.store.GetGroceryItemByID(id)
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+
+	m.editing = item
+	m.editInputs = make([]textinput.Model, len(editLabels))
+	for i := range m.editInputs {
+		input := textinput.New()
+		input.CharLimit = 156
+		input.Width = 36
+		input.Prompt = ""
+		m.editInputs[i] = input
+	}
+	m.editInputs[editNameField].SetValue(item.Name)
+	m.editInputs[editCountField].SetValue(strconv.FormatFloat(item.Count, 'f', -1, 64))
+	m.editInputs[editCountField].CharLimit = 9
+	m.editInputs[editUnitField].SetValue(item.Unit)
+	m.editInputs[editUnitField].CharLimit = 24
+	m.editInputs[editMinCountField].SetValue(strconv.FormatFloat(item.MinCount, 'f', -1, 64))
+	m.editInputs[editMinCountField].CharLimit = 9
+	m.editInputs[editExpiresField].SetValue(formatDate(item.ExpiresAt))
+	m.editInputs[editExpiresField].Placeholder = dateLayout
+	m.editInputs[editExpiresField].CharLimit = len(dateLayout)
+	m.editCategory = categoryChoice(m, item.CategoryID)
+	m.editLocation = locationChoice(m, item.LocationID)
+
+	m.editFocus = editNameField
+	m.state = editView
+	m.table.Blur()
+
+	return m, m.editInputs[m.editFocus].Focus()
+}
+
+func closeEdit(m mainModel) mainModel {
+	m.editInputs = nil
+	m.state = tableView
+	m.table.Focus()
+
+	return m
+}
+
+// saveEdit persists the edit form and refreshes the edited row.
+func saveEdit(m mainModel) (mainModel, error) {
+	count, err := strconv.ParseFloat(strings.TrimSpace(m.editInputs[editCountField].Value()), 64)
+	if err != nil {
+		return m, errors.New("Count must be a number.")
+	}
+
+	minCount, err := strconv.ParseFloat(strings.TrimSpace(m.editInputs[editMinCountField].Value()), 64)
+	if err != nil {
+		return m, errors.New("Minimum count must be a number.")
+	}
+
+	expiresAt, err := parseDate(m.editInputs[editExpiresField].Value())
+	if err != nil {
+		return m, err
+	}
+
+	item, err := m.store.UpdateGroceryItem(db.GroceryItem{
+		Model:      m.editing.Model,
+		Name:       m.editInputs[editNameField].Value(),
+		Count:      count,
+		Unit:       m.editInputs[editUnitField].Value(),
+		CategoryID: categoryIDAt(m, m.editCategory),
+		LocationID: locationIDAt(m, m.editLocation),
+		ExpiresAt:  expiresAt,
+		MinCount:   minCount,
+	})
+	if err != nil {
+		return m, err
+	}
+
+	m = pushUndo(m, undoStep{kind: undoUpdate, description: "editing " + item.Name, before: m.editing, after: item})
+
+	return reloadInventory(closeEdit(m), item.ID), nil
+}
+
+func updateEdit(m mainModel, msg tea.KeyMsg) (mainModel, tea.Cmd) {
+	var cmd tea.Cmd
+	var err error
+
+	switch msg.String() {
+	case "esc":
+		return closeEdit(m), nil
+	case "enter":
+		m, err = saveEdit(m)
+		m.err = err
+		return m, nil
+	case "tab", "down", "up":
+		m.editInputs[m.editFocus].Blur()
+		step := 1
+		if msg.String() == "up" {
+			step = len(m.editInputs) - 1
+		}
+		m.editFocus = (m.editFocus + step) % len(m.editInputs)
+		return m, m.editInputs[m.editFocus].Focus()
+	}
+
+	// Category and location are picked with ←/→ rather than typed.
+	switch m.editFocus {
+	case editCategoryField:
+		switch msg.String() {
+		case "left":
+			m.editCategory = cycleCategory(m, m.editCategory, -1)
+		case "right":
+			m.editCategory = cycleCategory(m, m.editCategory, 1)
+		}
+		return m, nil
+	case editLocationField:
+		switch msg.String() {
+		case "left":
+			m.editLocation = cycleLocation(m, m.editLocation, -1)
+		case "right":
+			m.editLocation = cycleLocation(m, m.editLocation, 1)
+		}
+		return m, nil
+	}
+
+	m.editInputs[m.editFocus], cmd = m.editInputs[m.editFocus].Update(msg)
+	return m, cmd
+}
+
+func getEditUI(m mainModel) string {
+	lines := []string{highlight.Bold(true).Render("Editing " + m.editing.Name), ""}
+
+	for i, input := range m.editInputs {
+		label := detailLabelStyle.Render(editLabels[i])
+		if i == m.editFocus {
+			label = detailLabelStyle.Foreground(theme.pink).Render(editLabels[i])
+		}
+		value := input.View()
+		switch i {
+		case editCategoryField:
+			value = "‹ " + categoryLabel(m, m.editCategory, "none") + " ›"
+		case editLocationField:
+			value = "‹ " + locationLabel(m, locationIDAt(m, m.editLocation), "none") + " ›"
+		}
+		lines = append(lines, lipgloss.JoinHorizontal(lipgloss.Top, label, value))
+	}
+
+	return detailStyle.Render(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	db "github.com/lundjrl/go-bubble-tea-playground/shared/database"
+)
+
+const dateLayout = "2006-01-02"
+
+// parseDate reads a YYYY-MM-DD date in local time. A blank value means no date.
+func parseDate(value string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+
+	date, err := time.ParseInLocation(dateLayout, value, time.Local)
+	if err != nil {
+		return nil, errors.New("Expiry date must look like 2006-01-02.")
+	}
+
+	return &date, nil
+}
+
+func formatDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Local().Format(dateLayout)
+}
+
+// daysUntil counts whole calendar days from today to t, negative once t has passed.
+// Both dates are read in now's time zone and compared as UTC midnights, where
+// every day is 24 hours, so a daylight saving change in between doesn't lose
+// or gain a day.
+func daysUntil(t time.Time, now time.Time) int {
+	t = t.In(now.Location())
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return int(day.Sub(today).Hours() / 24)
+}
+
+// expiringItems returns the stocked items that expire within the configured
+// window, soonest first. Items that already expired are included.
+func expiringItems(m mainModel, now time.Time) []db.GroceryItem {
+	items := []db.GroceryItem{}
+
+	for _, item := range m.items {
+		if item.ExpiresAt == nil || item.Count <= 0 {
+			continue
+		}
+		if daysUntil(*item.ExpiresAt, now) > m.settings.expiryWindow {
+			continue
+		}
+		items = append(items, item)
+	}
+
+	slices.SortStableFunc(items, func(a, b db.GroceryItem) int {
+		return a.ExpiresAt.Compare(*b.ExpiresAt)
+	})
+
+	return items
+}
+
+func expiryLabel(days int) string {
+	switch {
+	case days < -1:
+		return fmt.Sprintf("expired %d days ago", -days)
+	case days == -1:
+		return "expired yesterday"
+	case days == 0:
+		return "expires today"
+	case days == 1:
+		return "expires tomorrow"
+	}
+	return fmt.Sprintf("expires in %d days", days)
+}
+
+func getExpiringUI(m mainModel) string {
+	now := time.Now()
+	items := expiringItems(m, now)
+
+	title := listCursorStyle.Render(fmt.Sprintf("Expiring in the next %d days", m.settings.expiryWindow))
+	if len(items) == 0 {
+		return title + "\n" + listItemStyle.Foreground(theme.lavender).Render("Nothing is about to go off.")
+	}
+
+	lines := []string{title}
+
+	for _, item := range items {
+		days := daysUntil(*item.ExpiresAt, now)
+		line := fmt.Sprintf("%s  %-20s %s", formatDate(item.ExpiresAt), item.Name, expiryLabel(days))
+
+		if days < 0 {
+			lines = append(lines, listItemStyle.Foreground(theme.red).Render(line))
+		} else {
+			lines = append(lines, listItemStyle.Render(line))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
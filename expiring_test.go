@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDaysUntil(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no time zone data:", err)
+	}
+
+	at := func(year int, month time.Month, day int, hour int) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, newYork)
+	}
+
+	tests := []struct {
+		name    string
+		expires time.Time
+		now     time.Time
+		want    int
+	}{
+		{"today", at(2026, 6, 1, 0), at(2026, 6, 1, 18), 0},
+		{"tomorrow", at(2026, 6, 2, 0), at(2026, 6, 1, 23), 1},
+		{"yesterday", at(2026, 5, 31, 0), at(2026, 6, 1, 8), -1},
+		{"across spring forward", at(2026, 3, 12, 0), at(2026, 3, 5, 12), 7},
+		{"across fall back", at(2026, 11, 3, 0), at(2026, 10, 30, 23), 4},
+		{"stored in UTC", at(2026, 6, 2, 0).UTC(), at(2026, 6, 1, 21), 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := daysUntil(test.expires, test.now); got != test.want {
+				t.Errorf("daysUntil() = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
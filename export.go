@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	db "github.com/lundjrl/go-bubble-tea-playground/shared/database"
+)
+
+func exportCommand(fs *flag.FlagSet) func(db.InventoryStore, []string) error {
+	out := fs.String("out", "", "file to write the backup to (default stdout)")
+
+	return func(store db.InventoryStore, args []string) error {
+		if len(args) > 0 {
+			return usagef("export doesn't take arguments, use --out to name the file.")
+		}
+
+		snapshot, err := store.ExportSnapshot()
+		if err != nil {
+			return err
+		}
+
+		var buffer bytes.Buffer
+		if err := writeJSON(&buffer, snapshot); err != nil {
+			return err
+		}
+
+		if *out == "" {
+			_, err := os.Stdout.Write(buffer.Bytes())
+			return err
+		}
+
+		if err := writeFileAtomic(*out, buffer.Bytes()); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "Exported %s to %s.\n", pluralize(len(snapshot.Items), "item"), *out)
+
+		return nil
+	}
+}
+
+// writeFileAtomic writes data next to path and renames it into place, so an
+// interrupted write never leaves half a file behind.
+func writeFileAtomic(path string, data []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
+
+// readSnapshot reads a backup written by `chef export`.
+func readSnapshot(path string) (db.Snapshot, error) {
+	var snapshot db.Snapshot
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return snapshot, err
+	}
+
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return snapshot, fmt.Errorf("%s isn't a chef backup: %w", path, err)
+	}
+
+	return snapshot, snapshot.Validate()
+}
+
+func importBackup(store db.InventoryStore, path string, replace bool, dryRun bool) error {
+	snapshot, err := readSnapshot(path)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		fmt.Printf("%s is a valid backup from %s with %s, %s and %d grocery list entries. Nothing was saved.\n",
+			path, snapshot.ExportedAt.Local().Format("Jan 02 2006 15:04"),
+			pluralize(len(snapshot.Items), "item"), pluralize(len(snapshot.Events), "history event"), len(snapshot.ShoppingList))
+		return nil
+	}
+
+	imported, err := store.ImportSnapshot(snapshot, replace)
+	if err != nil {
+		return err
+	}
+
+	if replace {
+		fmt.Printf("Replaced the inventory with %s from %s.\n", pluralize(imported, "item"), path)
+	} else {
+		fmt.Printf("Merged %s from %s. Items chef already had were left as they are.\n", pluralize(imported, "new item"), path)
+	}
+
+	return nil
+}
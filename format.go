@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	db "github.com/lundjrl/go-bubble-tea-playground/shared/database"
+)
+
+// outputFormat is how listing commands print their results.
+type outputFormat string
+
+const (
+	formatTable outputFormat = "table"
+	formatJSON  outputFormat = "json"
+	formatCSV   outputFormat = "csv"
+	formatTSV   outputFormat = "tsv"
+)
+
+func parseFormat(text string) (outputFormat, error) {
+	switch format := outputFormat(text); format {
+	case formatTable, formatJSON, formatCSV, formatTSV:
+		return format, nil
+	}
+
+	return "", usagef("Unknown format %q. Use table, json, csv or tsv.", text)
+}
+
+// itemRecord is a grocery item as scripts see it: the GroceryItem json fields
+// with the category and location resolved to names.
+type itemRecord struct {
+	ID         uint                `json:"id"`
+	Name       string              `json:"name"`
+	Count      float64             `json:"count"`
+	Unit       string              `json:"unit"`
+	CategoryID *uint               `json:"categoryId"`
+	Category   string              `json:"category"`
+	LocationID *uint               `json:"locationId"`
+	Location   string              `json:"location"`
+	ExpiresAt  *time.Time          `json:"expiresAt"`
+	MinCount   float64             `json:"minCount"`
+	CreatedAt  time.Time           `json:"createdAt"`
+	UpdatedAt  time.Time           `json:"updatedAt"`
+	History    []db.InventoryEvent `json:"history,omitempty"`
+}
+
+// recordColumns are the CSV and TSV headers, in the order of record.fields.
+var recordColumns = []string{"id", "name", "count", "unit", "categoryId", "category", "locationId", "location", "expiresAt", "minCount", "createdAt", "updatedAt"}
+
+func newItemRecord(item db.GroceryItem, locations []db.Location) itemRecord {
+	record := itemRecord{
+		ID:         item.ID,
+		Name:       item.Name,
+		Count:      item.Count,
+		Unit:       item.Unit,
+		CategoryID: item.CategoryID,
+		Category:   categoryName(item),
+		LocationID: item.LocationID,
+		ExpiresAt:  item.ExpiresAt,
+		MinCount:   item.MinCount,
+		CreatedAt:  item.CreatedAt,
+		UpdatedAt:  item.UpdatedAt,
+	}
+
+	if item.LocationID != nil {
+		record.Location = db.LocationPath(locations, *item.LocationID)
+	}
+
+	return record
+}
+
+func optionalID(id *uint) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.FormatUint(uint64(*id), 10)
+}
+
+func (r itemRecord) fields() []string {
+	return []string{
+		strconv.FormatUint(uint64(r.ID), 10),
+		r.Name,
+		strconv.FormatFloat(r.Count, 'f', -1, 64),
+		r.Unit,
+		optionalID(r.CategoryID),
+		r.Category,
+		optionalID(r.LocationID),
+		r.Location,
+		formatDate(r.ExpiresAt),
+		strconv.FormatFloat(r.MinCount, 'f', -1, 64),
+		r.CreatedAt.Format(time.RFC3339),
+		r.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func writeJSON(w io.Writer, value any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// writeDelimited writes records as CSV, or as TSV when comma is a tab.
+func writeDelimited(w io.Writer, comma rune, records []itemRecord) error {
+	out := csv.NewWriter(w)
+	out.Comma = comma
+
+	if err := out.Write(recordColumns); err != nil {
+		return err
+	}
+	for _, record := range records {
+		if err := out.Write(record.fields()); err != nil {
+			return err
+		}
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+// writeItems prints records in format. JSON is always an array, even for one
+// item, so scripts don't need to special-case it.
+func writeItems(w io.Writer, format outputFormat, records []itemRecord) error {
+	switch format {
+	case formatJSON:
+		return writeJSON(w, records)
+	case formatCSV:
+		return writeDelimited(w, ',', records)
+	case formatTSV:
+		return writeDelimited(w, '\t', records)
+	}
+
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "ID\tNAME\tCOUNT\tCATEGORY\tLOCATION\tEXPIRES")
+
+	for _, record := range records {
+		count := formatQuantity(record.Count, record.Unit)
+		if record.MinCount > 0 && record.Count < record.MinCount {
+			count += " ▼"
+		}
+		fmt.Fprintf(table, "%d\t%s\t%s\t%s\t%s\t%s\n", record.ID, record.Name, count, record.Category, record.Location, formatDate(record.ExpiresAt))
+	}
+
+	return table.Flush()
+}
+
+// writeItemDetail prints one item with its history. CSV and TSV have no room
+// for the history, so they print the item's row alone.
+func writeItemDetail(w io.Writer, format outputFormat, record itemRecord) error {
+	switch format {
+	case formatJSON:
+		return writeJSON(w, record)
+	case formatCSV, formatTSV:
+		record.History = nil
+		return writeItems(w, format, []itemRecord{record})
+	}
+
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(table, "Name\t%s\n", record.Name)
+	fmt.Fprintf(table, "Count\t%s\n", formatQuantity(record.Count, record.Unit))
+	if record.MinCount > 0 {
+		fmt.Fprintf(table, "Min count\t%s\n", formatQuantity(record.MinCount, record.Unit))
+	}
+	fmt.Fprintf(table, "Category\t%s\n", record.Category)
+	fmt.Fprintf(table, "Location\t%s\n", record.Location)
+	fmt.Fprintf(table, "Expires\t%s\n", formatDate(record.ExpiresAt))
+	fmt.Fprintf(table, "Added\t%s\n", record.CreatedAt.Local().Format("Jan 02 2006 15:04"))
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	if len(record.History) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(w, "\nHistory")
+	table = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, event := range record.History {
+		delta := ""
+		if event.Delta != 0 {
+			delta = formatDelta(event.Delta, event.Unit)
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", event.CreatedAt.Local().Format("Jan 02 15:04"), event.Kind, delta, event.Note)
+	}
+
+	return table.Flush()
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	db "github.com/lundjrl/go-bubble-tea-playground/shared/database"
+)
+
+// globalFlags are the flags that come before the command name.
+func globalFlags() (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet("chef", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	dbPath := fs.String("db", "", "database file to use (default $"+db.PathEnv+", then $XDG_DATA_HOME/chef/chef.db)")
+
+	return fs, dbPath
+}
+
+func printFlags(w io.Writer, fs *flag.FlagSet) {
+	hasFlags := false
+	fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+	if !hasFlags {
+		return
+	}
+
+	fmt.Fprintln(w, "\nFlags:")
+	fs.SetOutput(w)
+	fs.PrintDefaults()
+	fs.SetOutput(io.Discard)
+}
+
+func printExamples(w io.Writer, examples []string) {
+	if len(examples) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "\nExamples:")
+	for _, example := range examples {
+		fmt.Fprintf(w, "  %s\n", example)
+	}
+}
+
+// printUsage lists every command, for `chef help` and mistyped commands.
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "chef keeps track of what's in your kitchen.")
+	fmt.Fprintln(w, "\nUsage:\n  chef [--db file] <command> [arguments]")
+
+	fmt.Fprintln(w, "\nCommands:")
+	table := tabwriter.NewWriter(w, 0, 4, 3, ' ', 0)
+	for _, c := range commands {
+		fmt.Fprintf(table, "  %s\t%s\n", c.name, c.about)
+	}
+	table.Flush()
+
+	fs, _ := globalFlags()
+	printFlags(w, fs)
+
+	printExamples(w, []string{
+		"chef init",
+		"chef add milk --count 2 --unit gallon",
+		"chef use eggs 3",
+		"chef list --low --format json",
+	})
+
+	fmt.Fprintln(w, "\nRun `chef help <command>` for a command's flags and examples.")
+}
+
+// printCommandUsage describes one command, with the flags declared on fs.
+func printCommandUsage(w io.Writer, c command, fs *flag.FlagSet) {
+	usage := strings.TrimSpace("chef " + c.name + " " + c.args)
+	fmt.Fprintf(w, "Usage: %s\n\n%s.\n", usage, strings.ToUpper(c.about[:1])+c.about[1:])
+
+	printFlags(w, fs)
+	printExamples(w, c.examples)
+}
+
+func helpCommand(_ string, args []string) error {
+	switch len(args) {
+	case 0:
+		printUsage(os.Stdout)
+		return nil
+	case 1:
+		c, found := findCommand(args[0])
+		if !found {
+			return usagef("There's no %s command. Run `chef help` to see them all.", args[0])
+		}
+		fs, _ := commandFlags(c)
+		printCommandUsage(os.Stdout, c, fs)
+		return nil
+	}
+
+	return usagef("Please ask about one command, like `chef help add`.")
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	db "github.com/lundjrl/go-bubble-tea-playground/shared/database"
+	"github.com/lundjrl/go-bubble-tea-playground/shared/ingredient"
+	"github.com/lundjrl/go-bubble-tea-playground/shared/units"
+)
+
+// importColumns maps the header spellings chef understands to the field they
+// fill. Headers are matched case-insensitively and other columns are ignored,
+// so a file written by `chef list --format csv` imports as is.
+var importColumns = map[string]string{
+	"name":      "name",
+	"item":      "name",
+	"count":     "count",
+	"quantity":  "count",
+	"qty":       "count",
+	"unit":      "unit",
+	"category":  "category",
+	"location":  "location",
+	"expires":   "expires",
+	"expiresat": "expires",
+	"expiry":    "expires",
+	"min":       "min",
+	"mincount":  "min",
+}
+
+// importRow is one line of the file, already turned into an item.
+type importRow struct {
+	line  int
+	draft db.GroceryItem
+}
+
+// rowError is a line of the file that couldn't be imported.
+type rowError struct {
+	line int
+	err  error
+}
+
+func importCommand(fs *flag.FlagSet) func(db.InventoryStore, []string) error {
+	dryRun := fs.Bool("dry-run", false, "show what would change without saving anything")
+	replace := fs.Bool("replace", false, "replace everything with the backup instead of merging it in")
+
+	return func(store db.InventoryStore, args []string) error {
+		switch {
+		case len(args) == 2 && args[0] == "csv":
+			if *replace {
+				return usagef("--replace only works with backups from `chef export`.")
+			}
+			return importCSV(store, args[1], *dryRun)
+		case len(args) == 1 && args[0] != "csv":
+			return importBackup(store, args[0], *replace, *dryRun)
+		}
+
+		return usagef("Please give a file to import, like `chef import backup.json` or `chef import csv pantry.csv`.")
+	}
+}
+
+// headerIndex finds which column holds each field.
+func headerIndex(header []string) (map[string]int, error) {
+	index := map[string]int{}
+
+	for i, column := range header {
+		key := strings.ToLower(strings.Join(strings.Fields(column), ""))
+		key = strings.TrimPrefix(key, "\ufeff")
+		if field, ok := importColumns[key]; ok {
+			if _, seen := index[field]; !seen {
+				index[field] = i
+			}
+		}
+	}
+
+	if _, ok := index["name"]; !ok {
+		return nil, errors.New("The file needs a name column.")
+	}
+
+	return index, nil
+}
+
+// parseImportCount reads a count cell, which may carry its own unit ("500g").
+func parseImportCount(text string) (float64, string, error) {
+	if count, err := strconv.ParseFloat(text, 64); err == nil {
+		if count < 0 {
+			return 0, "", errors.New("Counts can't be negative.")
+		}
+		return count, "", nil
+	}
+
+	return ingredient.ParseAmount(text)
+}
+
+// parseImportRow turns the cells of one line into an item draft.
+func parseImportRow(store db.InventoryStore, index map[string]int, record []string, defaultCount int) (db.GroceryItem, error) {
+	cell := func(field string) string {
+		i, ok := index[field]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	draft := db.GroceryItem{Name: cell("name"), Count: float64(defaultCount)}
+	if draft.Name == "" {
+		return draft, errors.New("The name is empty.")
+	}
+
+	var err error
+
+	if text := cell("count"); text != "" {
+		if draft.Count, draft.Unit, err = parseImportCount(text); err != nil {
+			return draft, err
+		}
+	}
+	if unit := cell("unit"); unit != "" {
+		draft.Unit = unit
+	}
+	if text := cell("min"); text != "" {
+		if draft.MinCount, err = strconv.ParseFloat(text, 64); err != nil || draft.MinCount < 0 {
+			return draft, fmt.Errorf("%q isn't a minimum count.", text)
+		}
+	}
+	if draft.CategoryID, err = findCategory(store, cell("category")); err != nil {
+		return draft, err
+	}
+	if draft.LocationID, err = findLocation(store, cell("location")); err != nil {
+		return draft, err
+	}
+	if draft.ExpiresAt, err = parseDate(cell("expires")); err != nil {
+		return draft, err
+	}
+
+	return draft, nil
+}
+
+// readImportFile reads every row of a CSV, or TSV when the file ends in .tsv.
+// Rows that can't be read are reported rather than stopping the import.
+func readImportFile(store db.InventoryStore, path string) ([]importRow, []rowError, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	if strings.EqualFold(filepath.Ext(path), ".tsv") {
+		reader.Comma = '\t'
+	}
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, errors.New("The file is empty.")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	index, err := headerIndex(header)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	values, err := store.GetSettings()
+	if err != nil {
+		return nil, nil, err
+	}
+	defaultCount := loadSettings(values).defaultCount
+
+	rows := []importRow{}
+	failed := []rowError{}
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			failed = append(failed, rowError{parseErr.Line, parseErr.Err})
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		draft, err := parseImportRow(store, index, record, defaultCount)
+		if err != nil {
+			failed = append(failed, rowError{line, err})
+			continue
+		}
+
+		rows = append(rows, importRow{line, draft})
+	}
+
+	return rows, failed, nil
+}
+
+// previewImportRow describes what importing draft would do, without saving.
+// pending holds the items as earlier rows of the same file would leave them,
+// keyed by name, and is updated with this row.
+func previewImportRow(store db.InventoryStore, pending map[string]db.GroceryItem, draft db.GroceryItem) (string, error) {
+	key := strings.Join(strings.Fields(strings.ToLower(draft.Name)), " ")
+	unit := units.Canonical(draft.Unit)
+
+	existing, ok := pending[key]
+	if !ok {
+		var err error
+		if existing, err = store.GetGroceryItemByName(key); err != nil {
+			pending[key] = db.GroceryItem{Name: key, Count: draft.Count, Unit: unit, MinCount: draft.MinCount}
+			preview := fmt.Sprintf("add %s %s", formatQuantity(draft.Count, unit), key)
+			if draft.MinCount > 0 {
+				preview += ", keeping at least " + formatQuantity(draft.MinCount, unit)
+			}
+			return preview, nil
+		}
+	}
+
+	// Mirror the store's restock rule: only an empty item can change unit.
+	converted, err := units.Convert(draft.Count, unit, existing.Unit)
+	if err != nil && existing.Count != 0 {
+		return "", fmt.Errorf("%s is tracked in %s. %w", existing.Name, existing.Unit, err)
+	}
+	if err != nil {
+		existing.Unit = unit
+		converted = draft.Count
+	}
+	existing.Count += converted
+
+	preview := fmt.Sprintf("restock %s to %s", existing.Name, formatQuantity(existing.Count, existing.Unit))
+	if draft.MinCount > 0 && draft.MinCount != existing.MinCount {
+		preview += ", keeping at least " + formatQuantity(draft.MinCount, existing.Unit)
+		if existing.MinCount > 0 {
+			preview += " instead of " + formatQuantity(existing.MinCount, existing.Unit)
+		}
+		existing.MinCount = draft.MinCount
+	}
+	pending[key] = existing
+
+	return preview, nil
+}
+
+// reportRowErrors lists the lines that couldn't be imported on stderr.
+func reportRowErrors(failed []rowError) {
+	sort.Slice(failed, func(i, j int) bool { return failed[i].line < failed[j].line })
+	for _, row := range failed {
+		fmt.Fprintf(os.Stderr, "line %d: %s\n", row.line, row.err)
+	}
+}
+
+// importCSV checks every row before saving any, and saves them all in one go,
+// so a file with mistakes can be fixed and imported again without restocking
+// the good rows twice.
+func importCSV(store db.InventoryStore, path string, dryRun bool) error {
+	rows, failed, err := readImportFile(store, path)
+	if err != nil {
+		return err
+	}
+
+	pending := map[string]db.GroceryItem{}
+	previews := map[int]string{}
+
+	for _, row := range rows {
+		preview, err := previewImportRow(store, pending, row.draft)
+		if err != nil {
+			failed = append(failed, rowError{row.line, err})
+			continue
+		}
+		previews[row.line] = preview
+	}
+
+	if dryRun {
+		for _, row := range rows {
+			if preview, ok := previews[row.line]; ok {
+				fmt.Printf("line %d: would %s\n", row.line, preview)
+			}
+		}
+		fmt.Printf("Dry run, nothing was saved. %s would be imported.\n", pluralize(len(previews), "row"))
+	}
+
+	if len(failed) > 0 {
+		reportRowErrors(failed)
+		return fmt.Errorf("%s couldn't be imported, so nothing was saved. Please fix them and import the file again.", pluralize(len(failed), "row"))
+	}
+
+	if dryRun {
+		return nil
+	}
+
+	drafts := make([]db.GroceryItem, len(rows))
+	for i, row := range rows {
+		drafts[i] = row.draft
+	}
+
+	added, err := store.ImportGroceryItems(drafts)
+	if err != nil {
+		return fmt.Errorf("%w Nothing was saved.", err)
+	}
+
+	fmt.Printf("Imported %s: %d added, %d restocked.\n", pluralize(len(drafts), "item"), added, len(drafts)-added)
+
+	return nil
+}
+
+func pluralize(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
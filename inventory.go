@@ -0,0 +1,151 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/charmbracelet/bubbles/table"
+	db "github.com/lundjrl/go-bubble-tea-playground/shared/database"
+)
+
+// reloadInventory refetches the inventory, applies the category filter and
+// grouping, and puts the table cursor on selectID when it's still shown.
+func reloadInventory(m mainModel, selectID uint) mainModel {
+	items, err := m.store.GetGroceryItems()
+	if err != nil {
+		m.err = err
+		return m
+	}
+
+	m.items = items
+
+	// Count changes can move par level shortfalls on or off the list, and
+	// deletes fill the trash.
+	m = reloadTrash(reloadList(m))
+
+	visible := visibleItems(m)
+	rows := []table.Row{}
+	cursor := min(m.table.Cursor(), max(0, len(visible)-1))
+
+	for i, item := range visible {
+		rows = append(rows, itemRow(item))
+		if item.ID == selectID {
+			cursor = i
+		}
+	}
+
+	m.table.SetRows(rows)
+	m.table.SetCursor(cursor)
+
+	return m
+}
+
+// visibleItems returns the items that pass the category and location
+// filters, sorted by category when grouping is on.
+func visibleItems(m mainModel) []db.GroceryItem {
+	items := []db.GroceryItem{}
+
+	filter := categoryIDAt(m, m.categoryFilter)
+	var inLocation map[uint]bool
+	if m.locationFilter != nil {
+		inLocation = db.LocationSubtree(m.locations, *m.locationFilter)
+	}
+
+	for _, item := range m.items {
+		if filter != nil && (item.CategoryID == nil || *item.CategoryID != *filter) {
+			continue
+		}
+		if inLocation != nil && (item.LocationID == nil || !inLocation[*item.LocationID]) {
+			continue
+		}
+		items = append(items, item)
+	}
+
+	if m.groupByCategory {
+		slices.SortStableFunc(items, func(a, b db.GroceryItem) int {
+			// Uncategorized items go last.
+			if (a.Category == nil) != (b.Category == nil) {
+				if a.Category == nil {
+					return 1
+				}
+				return -1
+			}
+			return cmp.Or(cmp.Compare(categoryName(a), categoryName(b)), cmp.Compare(a.Name, b.Name))
+		})
+	}
+
+	return items
+}
+
+func categoryName(item db.GroceryItem) string {
+	if item.Category == nil {
+		return ""
+	}
+	return item.Category.Name
+}
+
+// categoryIDAt maps a category choice to its ID. Choice 0 means no category,
+// choice i is m.categories[i-1].
+func categoryIDAt(m mainModel, choice int) *uint {
+	if choice <= 0 || choice > len(m.categories) {
+		return nil
+	}
+	return &m.categories[choice-1].ID
+}
+
+// categoryChoice is the inverse of categoryIDAt.
+func categoryChoice(m mainModel, id *uint) int {
+	if id == nil {
+		return 0
+	}
+	for i, category := range m.categories {
+		if category.ID == *id {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func categoryLabel(m mainModel, choice int, none string) string {
+	if choice <= 0 || choice > len(m.categories) {
+		return none
+	}
+	return m.categories[choice-1].Name
+}
+
+// cycleCategory steps through the category choices, wrapping around.
+func cycleCategory(m mainModel, choice int, step int) int {
+	choices := len(m.categories) + 1
+	return (choice + step + choices) % choices
+}
+
+func cycleCategoryFilter(m mainModel) mainModel {
+	m.categoryFilter = cycleCategory(m, m.categoryFilter, 1)
+	m.table.SetCursor(0)
+	return reloadInventory(m, 0)
+}
+
+func clearFilters(m mainModel) mainModel {
+	m.categoryFilter = 0
+	m.locationFilter = nil
+	return reloadInventory(m, 0)
+}
+
+func toggleGrouping(m mainModel) mainModel {
+	id, _ := selectedItemID(m)
+	m.groupByCategory = !m.groupByCategory
+	return reloadInventory(m, id)
+}
+
+// inventoryViewLabel describes the active filter and grouping.
+func inventoryViewLabel(m mainModel) string {
+	label := fmt.Sprintf("showing: %s", categoryLabel(m, m.categoryFilter, "all categories"))
+	if m.locationFilter != nil {
+		label += " in " + locationLabel(m, m.locationFilter, "")
+	}
+	if m.groupByCategory {
+		label += " • grouped by category"
+	}
+	return label
+}
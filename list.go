@@ -0,0 +1,180 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	listItemStyle    = lipgloss.NewStyle().Foreground(theme.fg).MarginLeft(6)
+	listCursorStyle  = lipgloss.NewStyle().Foreground(theme.pink).Bold(true).MarginLeft(6)
+	listCheckedStyle = lipgloss.NewStyle().Foreground(theme.lavender).Strikethrough(true).MarginLeft(6)
+	listInputStyle   = lipgloss.NewStyle().
+				BorderStyle(lipgloss.HiddenBorder()).
+				Width(49).
+				MarginLeft(5).MarginTop(1)
+	focusedListInputStyle = listInputStyle.
+				BorderStyle(lipgloss.RoundedBorder()).
+				BorderForeground(theme.pink)
+)
+
+func newListInput() textinput.Model {
+	input := textinput.New()
+	input.Placeholder = "add to the list?"
+	input.CharLimit = 156
+	input.Width = 49
+
+	return input
+}
+
+func reloadList(m mainModel) mainModel {
+	groceryList, err := m.store.GetShoppingListItems()
+	if err != nil {
+		m.err = err
+		return m
+	}
+
+	m.groceryList = groceryList
+	m.listCursor = min(m.listCursor, max(0, len(groceryList)-1))
+
+	return m
+}
+
+func getListItemsUI(m mainModel) string {
+	if len(m.groceryList) == 0 {
+		return listItemStyle.Foreground(theme.lavender).Render("Nothing on the list yet.")
+	}
+
+	lines := []string{}
+
+	for i, item := range m.groceryList {
+		checkbox := "[ ]"
+		if item.Checked {
+			checkbox = "[x]"
+		}
+
+		line := checkbox + " " + item.Name
+		if item.Quantity > 0 {
+			line += " (" + formatQuantity(item.Quantity, item.Unit) + ")"
+		}
+
+		switch {
+		case i == m.listCursor && !m.listInput.Focused():
+			lines = append(lines, listCursorStyle.Render("> "+line))
+		case item.Checked:
+			lines = append(lines, listCheckedStyle.Render("  "+line))
+		default:
+			lines = append(lines, listItemStyle.Render("  "+line))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func getListUI(m mainModel) string {
+	if m.currentTab != listTab {
+		return ""
+	}
+
+	titleStyle := lipgloss.NewStyle().
+		PaddingTop(2).
+		MarginLeft(6).
+		Height(2).
+		Bold(true).Foreground(theme.blue).
+		Render("Grocery List")
+
+	descriptionStyle := lipgloss.NewStyle().
+		Bold(true).PaddingTop(3).
+		Foreground(theme.lavender).
+		MarginLeft(2).
+		Render("What to pick up next trip")
+
+	line := lipgloss.NewStyle().
+		BorderForeground(theme.pink).
+		BorderTop(true).
+		BorderStyle(lipgloss.NormalBorder()).
+		PaddingTop(-1).
+		Width(50).
+		MarginLeft(6).Render()
+
+	input := listInputStyle.Render(m.listInput.View())
+	if m.listInput.Focused() {
+		input = focusedListInputStyle.Render(m.listInput.View())
+	}
+
+	spacer := lipgloss.NewStyle().
+		Height(1).Render(" ")
+
+	listHelperText := tipContainerStyle.MarginLeft(6).Width(60).Padding(1).Render("a: add item • space: check/uncheck • d: remove • q: exit")
+	if m.listInput.Focused() {
+		listHelperText = tipContainerStyle.MarginLeft(6).Width(60).Padding(1).Render("enter: add to list • esc: back to list")
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Top, lipgloss.JoinHorizontal(lipgloss.Center, titleStyle, descriptionStyle), line, spacer, getListItemsUI(m), input, listHelperText, spacer)
+}
+
+// updateList handles key presses while the grocery list tab is open.
+func updateList(m mainModel, msg tea.KeyMsg) (mainModel, tea.Cmd) {
+	var cmd tea.Cmd
+
+	if m.listInput.Focused() {
+		switch msg.String() {
+		case "enter":
+			item, err := m.store.CreateShoppingListItem(m.listInput.Value())
+			if err != nil {
+				m.err = err
+				return m, nil
+			}
+			m.groceryList = append(m.groceryList, item)
+			m.listCursor = len(m.groceryList) - 1
+			m.listInput.Reset()
+			return m, nil
+		case "esc", "tab":
+			m.listInput.Blur()
+			return m, nil
+		}
+
+		m.listInput, cmd = m.listInput.Update(msg)
+		return m, cmd
+	}
+
+	switch msg.String() {
+	case "up", "k":
+		if m.listCursor > 0 {
+			m.listCursor--
+		}
+	case "down", "j":
+		if m.listCursor < len(m.groceryList)-1 {
+			m.listCursor++
+		}
+	case "a", "tab":
+		return m, m.listInput.Focus()
+	case " ", "x", "enter":
+		if len(m.groceryList) == 0 {
+			return m, nil
+		}
+		item := &m.groceryList[m.listCursor]
+		if err := m.store.SetShoppingListItemChecked(item.ID, !item.Checked); err != nil {
+			m.err = err
+			return m, nil
+		}
+		item.Checked = !item.Checked
+	case "d", "delete", "backspace":
+		if len(m.groceryList) == 0 {
+			return m, nil
+		}
+		if err := m.store.DeleteShoppingListItem(m.groceryList[m.listCursor].ID); err != nil {
+			m.err = err
+			return m, nil
+		}
+		m.groceryList = append(m.groceryList[:m.listCursor], m.groceryList[m.listCursor+1:]...)
+		if m.listCursor >= len(m.groceryList) && m.listCursor > 0 {
+			m.listCursor--
+		}
+	}
+
+	return m, nil
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	db "github.com/lundjrl/go-bubble-tea-playground/shared/database"
+)
+
+// locationNode is a location placed in the tree, depth levels down.
+type locationNode struct {
+	location db.Location
+	depth    int
+}
+
+func newLocationInput() textinput.Model {
+	input := textinput.New()
+	input.CharLimit = 64
+	input.Width = 49
+
+	return input
+}
+
+// locationTree flattens the locations depth first, so each location is
+// followed by everything stored inside it.
+func locationTree(locations []db.Location) []locationNode {
+	children := map[uint][]db.Location{}
+	roots := []db.Location{}
+
+	for _, location := range locations {
+		if location.ParentID == nil {
+			roots = append(roots, location)
+		} else {
+			children[*location.ParentID] = append(children[*location.ParentID], location)
+		}
+	}
+
+	nodes := []locationNode{}
+	var walk func(level []db.Location, depth int)
+	walk = func(level []db.Location, depth int) {
+		for _, location := range level {
+			nodes = append(nodes, locationNode{location, depth})
+			walk(children[location.ID], depth+1)
+		}
+	}
+	walk(roots, 0)
+
+	return nodes
+}
+
+func reloadLocations(m mainModel) mainModel {
+	locations, err := m.store.GetLocations()
+	if err != nil {
+		m.err = err
+		return m
+	}
+
+	m.locations = locations
+	m.locationCursor = min(m.locationCursor, max(0, len(locationTree(locations))-1))
+
+	return m
+}
+
+// locationIDAt maps a location choice to its ID. Choice 0 means no location,
+// choice i is the i-th node of the location tree.
+func locationIDAt(m mainModel, choice int) *uint {
+	tree := locationTree(m.locations)
+	if choice <= 0 || choice > len(tree) {
+		return nil
+	}
+	return &tree[choice-1].location.ID
+}
+
+// locationChoice is the inverse of locationIDAt.
+func locationChoice(m mainModel, id *uint) int {
+	if id == nil {
+		return 0
+	}
+	for i, node := range locationTree(m.locations) {
+		if node.location.ID == *id {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func locationLabel(m mainModel, id *uint, none string) string {
+	if id == nil {
+		return none
+	}
+	return db.LocationPath(m.locations, *id)
+}
+
+// cycleLocation steps through the location choices, wrapping around.
+func cycleLocation(m mainModel, choice int, step int) int {
+	choices := len(m.locations) + 1
+	return (choice + step + choices) % choices
+}
+
+func getLocationTreeUI(m mainModel) string {
+	tree := locationTree(m.locations)
+
+	if len(tree) == 0 {
+		return listItemStyle.Foreground(theme.lavender).Render("No locations yet. Press A to add one.")
+	}
+
+	lines := []string{}
+
+	for i, node := range tree {
+		line := strings.Repeat("  ", node.depth) + "▸ " + node.location.Name
+
+		if i == m.locationCursor && !m.locationInput.Focused() {
+			lines = append(lines, listCursorStyle.Render("> "+line))
+		} else {
+			lines = append(lines, listItemStyle.Render("  "+line))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func getLocationsUI(m mainModel) string {
+	if m.currentTab != locationsTab {
+		return ""
+	}
+
+	titleStyle := lipgloss.NewStyle().
+		PaddingTop(2).
+		MarginLeft(6).
+		Height(2).
+		Bold(true).Foreground(theme.blue).
+		Render("Locations")
+
+	descriptionStyle := lipgloss.NewStyle().
+		Bold(true).PaddingTop(3).
+		Foreground(theme.lavender).
+		MarginLeft(2).
+		Render("Where everything lives")
+
+	line := lipgloss.NewStyle().
+		BorderForeground(theme.pink).
+		BorderTop(true).
+		BorderStyle(lipgloss.NormalBorder()).
+		PaddingTop(-1).
+		Width(50).
+		MarginLeft(6).Render()
+
+	input := listInputStyle.Render(m.locationInput.View())
+	if m.locationInput.Focused() {
+		input = focusedListInputStyle.Render(m.locationInput.View())
+	}
+
+	spacer := lipgloss.NewStyle().
+		Height(1).Render(" ")
+
+	locationsHelperText := tipContainerStyle.MarginLeft(6).Width(60).Padding(1).Render("enter: show items • a: add inside • A: add top level • d: remove")
+	if m.locationInput.Focused() {
+		locationsHelperText = tipContainerStyle.MarginLeft(6).Width(60).Padding(1).Render("enter: add location • esc: back to locations")
+	}
+	if tree := locationTree(m.locations); m.confirmingLocationDelete && len(tree) > 0 {
+		prompt := fmt.Sprintf("Remove %s? Items kept there move up a level. y: yes • n: no", tree[m.locationCursor].location.Name)
+		locationsHelperText = tipContainerStyle.MarginLeft(6).Width(60).Padding(1).BorderForeground(theme.pink).Render(prompt)
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Top, lipgloss.JoinHorizontal(lipgloss.Center, titleStyle, descriptionStyle), line, spacer, getLocationTreeUI(m), input, locationsHelperText, spacer)
+}
+
+// deleteLocation removes the highlighted location.
+func deleteLocation(m mainModel) mainModel {
+	tree := locationTree(m.locations)
+	if len(tree) == 0 {
+		return m
+	}
+
+	id := tree[m.locationCursor].location.ID
+	if err := m.store.DeleteLocation(id); err != nil {
+		m.err = err
+		return m
+	}
+	if m.locationFilter != nil && *m.locationFilter == id {
+		m.locationFilter = nil
+	}
+
+	return reloadInventory(reloadLocations(m), 0)
+}
+
+// updateLocations handles key presses while the locations tab is open.
+func updateLocations(m mainModel, msg tea.KeyMsg) (mainModel, tea.Cmd) {
+	var cmd tea.Cmd
+
+	if m.confirmingLocationDelete {
+		switch msg.String() {
+		case "y", "enter":
+			m = deleteLocation(m)
+		case "n", "esc":
+		default:
+			return m, nil
+		}
+		m.confirmingLocationDelete = false
+		return m, nil
+	}
+
+	tree := locationTree(m.locations)
+
+	if m.locationInput.Focused() {
+		switch msg.String() {
+		case "enter":
+			location, err := m.store.CreateLocation(m.locationInput.Value(), m.locationParent)
+			if err != nil {
+				m.err = err
+				return m, nil
+			}
+			m.locationInput.Reset()
+			m.locationInput.Blur()
+			m = reloadLocations(m)
+			m.locationCursor = max(0, locationChoice(m, &location.ID)-1)
+			return m, nil
+		case "esc":
+			m.locationInput.Reset()
+			m.locationInput.Blur()
+			return m, nil
+		}
+
+		m.locationInput, cmd = m.locationInput.Update(msg)
+		return m, cmd
+	}
+
+	switch msg.String() {
+	case "up", "k":
+		if m.locationCursor > 0 {
+			m.locationCursor--
+		}
+	case "down", "j":
+		if m.locationCursor < len(tree)-1 {
+			m.locationCursor++
+		}
+	case "a":
+		if len(tree) == 0 {
+			break
+		}
+		m.locationParent = &tree[m.locationCursor].location.ID
+		m.locationInput.Placeholder = "add inside " + tree[m.locationCursor].location.Name + "?"
+		return m, m.locationInput.Focus()
+	case "A":
+		m.locationParent = nil
+		m.locationInput.Placeholder = "add a location?"
+		return m, m.locationInput.Focus()
+	case "d", "delete":
+		if len(tree) == 0 {
+			break
+		}
+		if !m.settings.confirmDelete {
+			return deleteLocation(m), nil
+		}
+		m.confirmingLocationDelete = true
+	case "enter":
+		if len(tree) == 0 {
+			break
+		}
+		m.locationFilter = &tree[m.locationCursor].location.ID
+		m.currentTab = inventoryTab
+		m.table.SetCursor(0)
+		m = reloadInventory(m, 0)
+	}
+
+	return m, nil
+}
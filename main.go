@@ -1,8 +1,12 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"math"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/cursor"
@@ -10,17 +14,68 @@ import (
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/charmbracelet/lipgloss/list"
 	"github.com/charmbracelet/log"
 	db "github.com/lundjrl/go-bubble-tea-playground/shared/database"
+	"github.com/lundjrl/go-bubble-tea-playground/shared/ingredient"
 )
 
 type mainModel struct {
-	currentTab int
-	state      sessionState
-	table      table.Model
-	textInput  textinput.Model
-	err        error
+	store                    db.InventoryStore
+	currentTab               int
+	state                    sessionState
+	table                    table.Model
+	textInput                textinput.Model
+	items                    []db.GroceryItem
+	categories               []db.Category
+	addCategory              int
+	categoryFilter           int
+	groupByCategory          bool
+	locations                []db.Location
+	locationCursor           int
+	locationInput            textinput.Model
+	locationParent           *uint
+	locationFilter           *uint
+	groceryList              []db.ShoppingListItem
+	listCursor               int
+	listInput                textinput.Model
+	detail                   db.GroceryItem
+	detailEvents             []db.InventoryEvent
+	editing                  db.GroceryItem
+	editInputs               []textinput.Model
+	editFocus                int
+	editCategory             int
+	editLocation             int
+	countPrefix              string
+	trash                    []db.GroceryItem
+	trashCursor              int
+	confirmingPurge          bool
+	confirmingLocationDelete bool
+	undoStack                []undoStep
+	redoStack                []undoStep
+	settings                 appSettings
+	settingsCursor           int
+	confirmingWipe           bool
+	status                   string
+	err                      error
+}
+
+// Tabs in the order they're shown.
+const (
+	homeTab = iota
+	inventoryTab
+	listTab
+	locationsTab
+	trashTab
+	settingsTab
+)
+
+var tabs = []struct{ key, title string }{
+	{"h", "Home"},
+	{"i", "Inventory"},
+	{"g", "Grocery List"},
+	{"l", "Locations"},
+	{"t", "Trash"},
+	{"s", "Settings"},
 }
 
 // sessionState to track which model is focused.
@@ -30,32 +85,11 @@ const (
 	tableView sessionState = iota
 	inputView
 	welcomeView
+	detailView
+	editView
+	confirmDeleteView
 )
 
-type Theme struct {
-	blue     lipgloss.Color
-	pink     lipgloss.Color
-	yellow   lipgloss.Color
-	lavender lipgloss.Color
-	bg       lipgloss.Color
-	fg       lipgloss.Color
-}
-
-// blue #89b4fa
-// pink #f5c2e7
-// yellow #f9e2af
-// lavender #b4befe
-// bg #11111b
-// fg #cdd6f4
-
-var theme = Theme{
-	blue:     lipgloss.Color("#89b4fa"),
-	pink:     lipgloss.Color("#f5c2e7"),
-	yellow:   lipgloss.Color("#f9e2af"),
-	lavender: lipgloss.Color("#b4befe"),
-	bg:       lipgloss.Color("#11111b"),
-	fg:       lipgloss.Color("#cdd6f4")}
-
 var (
 	modelStyle = lipgloss.NewStyle().
 			Width(49).
@@ -118,106 +152,134 @@ var (
 		BorderRight(false)
 )
 
-func newModel() mainModel {
-	m := mainModel{state: tableView}
+// newModel builds the app on top of store, which is where every read and
+// write goes.
+func newModel(store db.InventoryStore) (mainModel, error) {
+	m := mainModel{store: store, state: tableView}
 
-	columns := []table.Column{
-		{Title: "ID", Width: 4},
-		{Title: "Name", Width: 15},
-		{Title: "Count", Width: 24},
+	values, err := m.store.GetSettings()
+
+	if err != nil {
+		return m, err
 	}
 
-	var items []db.GroceryItem
-	result := db.DBConn.Find(&items)
+	m.settings = loadSettings(values)
+	setTheme(m.settings.theme)
 
-	if result.Error != nil {
-		panic(result.Error)
+	if m, err = purgeExpiredTrash(m); err != nil {
+		return m, err
 	}
 
-	tableRows := []table.Row{}
+	columns := []table.Column{
+		{Title: "ID", Width: 4},
+		{Title: "Name", Width: 14},
+		{Title: "Category", Width: 11},
+		{Title: "Count", Width: 12},
+	}
 
-	for _, item := range items {
-		row := []string{fmt.Sprint(item.ID), item.Name, fmt.Sprint(item.Count)}
-		tableRows = append(tableRows, row)
+	categories, err := m.store.GetCategories()
+
+	if err != nil {
+		return m, err
 	}
 
 	t := table.New(
 		table.WithColumns(columns),
-		table.WithRows(tableRows),
 		table.WithFocused(true),
 		table.WithHeight(7),
 		table.WithWidth(49),
 	)
+	// Free up "d" for deleting rows and "u" for undo.
+	t.KeyMap.HalfPageDown.SetKeys("ctrl+d")
+	t.KeyMap.HalfPageUp.SetKeys("ctrl+u")
 
-	s := table.DefaultStyles()
-	s.Header = s.Header.
-		BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(theme.fg).
-		BorderBottom(true).
-		Bold(false)
-	s.Selected = s.Selected.
-		Foreground(theme.bg).
-		Background(theme.yellow).
-		Bold(true)
-	t.SetStyles(s)
+	t.SetStyles(tableStyles())
+
+	locations, err := m.store.GetLocations()
+
+	if err != nil {
+		return m, err
+	}
 
 	m.table = t
+	m.categories = categories
+	m.locations = locations
+	m.locationInput = newLocationInput()
+	m = reloadInventory(m, 0)
+	if m.err != nil {
+		return m, m.err
+	}
+
+	m.listInput = newListInput()
 	m.textInput = textinput.New()
 	m.textInput.Placeholder = "add an item?"
 	m.textInput.CharLimit = 156
 	m.textInput.Width = 49
 	m.err = nil
 	m.state = tableView
-	m.currentTab = 0
+	m.currentTab = homeTab
 
-	return m
+	return m, nil
 }
 
-func getTabUI(m mainModel) string {
-	gap := tabGap.Render(strings.Repeat(" ", max(0, 98)))
+// itemRow converts a grocery item into an inventory table row.
+func itemRow(item db.GroceryItem) table.Row {
+	count := formatQuantity(item.Count, item.Unit)
+	if lowStock(item) {
+		count += " ▼"
+	}
+	return table.Row{fmt.Sprint(item.ID), item.Name, categoryName(item), count}
+}
 
-	switch m.currentTab {
-	case 1:
-		return lipgloss.JoinHorizontal(
-			lipgloss.Bottom,
-			tab.Render("(h) Home"),
-			activeTab.Render("(i) Inventory"),
-			tab.Render("(g) Grocery List"),
-			tab.Render("(s) Settings"),
-			gap)
-	case 2:
-		return lipgloss.JoinHorizontal(
-			lipgloss.Bottom,
-			tab.Render("(h) Home"),
-			tab.Render("(i) Inventory"),
-			activeTab.Render("(g) Grocery List"),
-			tab.Render("(s) Settings"),
-			gap)
-	case 3:
-		return lipgloss.JoinHorizontal(
-			lipgloss.Bottom,
-			tab.Render("(h) Home"),
-			tab.Render("(i) Inventory"),
-			tab.Render("(g) Grocery List"),
-			activeTab.Render("(s) Settings"),
-			gap)
-	}
-
-	return lipgloss.JoinHorizontal(
-		lipgloss.Bottom,
-		activeTab.Render("(h) Home"),
-		tab.Render("(i) Inventory"),
-		tab.Render("(g) Grocery List"),
-		tab.Render("(s) Settings"),
-		gap)
+// lowStock reports whether an item has dropped below its par level.
+func lowStock(item db.GroceryItem) bool {
+	return item.MinCount > 0 && item.Count < item.MinCount
 }
 
-func enumerateList(items list.Items, i int) string {
-	return "\t\t\t ✓ "
+// formatQuantity renders a count with its unit, e.g. "1.5 lb".
+func formatQuantity(count float64, unit string) string {
+	quantity := strconv.FormatFloat(math.Round(count*100)/100, 'f', -1, 64)
+	if unit == "" {
+		return quantity
+	}
+	return quantity + " " + unit
+}
+
+// selectedItemID returns the ID of the highlighted inventory row.
+func selectedItemID(m mainModel) (uint, bool) {
+	row := m.table.SelectedRow()
+	if row == nil {
+		return 0, false
+	}
+
+	id, err := strconv.ParseUint(row[0], 10, 0)
+	if err != nil {
+		return 0, false
+	}
+
+	return uint(id), true
+}
+
+func getTabUI(m mainModel) string {
+	rendered := []string{}
+
+	for i, t := range tabs {
+		title := fmt.Sprintf("(%s) %s", t.key, t.title)
+		if i == m.currentTab {
+			rendered = append(rendered, activeTab.Render(title))
+		} else {
+			rendered = append(rendered, tab.Render(title))
+		}
+	}
+
+	row := lipgloss.JoinHorizontal(lipgloss.Bottom, rendered...)
+	gap := tabGap.Render(strings.Repeat(" ", max(0, 162-lipgloss.Width(row))))
+
+	return lipgloss.JoinHorizontal(lipgloss.Bottom, row, gap)
 }
 
 func getWelcomeUI(m mainModel) string {
-	if m.currentTab != 0 {
+	if m.currentTab != homeTab {
 		return ""
 	}
 
@@ -242,31 +304,35 @@ func getWelcomeUI(m mainModel) string {
 		Width(50).
 		MarginLeft(6).Render()
 
-	itemStyle := lipgloss.NewStyle().
-		Foreground(theme.pink).
-		TabWidth(4) // Tab width can be different per terminal
-
-	listItems := list.New(
-		"Check your inventory",
-		"Make a grocery list",
-		"Support James",
-	).ItemStyle(itemStyle).Enumerator(enumerateList)
-
 	spacer := lipgloss.NewStyle().
-		Height(3).Render(" ")
+		Height(1).Render(" ")
 
-	homeHelperText := tipContainerStyle.MarginLeft(6).Width(60).Padding(1).Render("i: go to inventory • g: go to list • s: go to settings")
+	homeHelperText := tipContainerStyle.MarginLeft(6).Width(70).Padding(1).Render("i: inventory • g: list • l: locations • t: trash • s: settings • q: exit")
 
-	return lipgloss.JoinVertical(lipgloss.Top, lipgloss.JoinHorizontal(lipgloss.Center, titleStyle, descriptionStyle), line, listItems.String(), spacer, homeHelperText, spacer)
+	return lipgloss.JoinVertical(lipgloss.Top, lipgloss.JoinHorizontal(lipgloss.Center, titleStyle, descriptionStyle), line, spacer, getExpiringUI(m), spacer, homeHelperText, spacer)
 }
 
 func getTableUI(m mainModel) string {
-	if m.currentTab != 1 {
+	if m.currentTab != inventoryTab {
 		return ""
 	}
 
-	focusedTable := lipgloss.JoinHorizontal(lipgloss.Top, focusedTableStyle.Render(m.table.View()), modelStyle.Render(m.textInput.View())+"\n")
-	unfocusedTable := lipgloss.JoinHorizontal(lipgloss.Top, baseTableStyle.Render(m.table.View()), focusedModelStyle.Render(m.textInput.View())+"\n")
+	input := m.textInput.View() + "\n" + detailLabelStyle.Render("Category") + "‹ " + categoryLabel(m, m.addCategory, "none") + " ›"
+
+	focusedTable := lipgloss.JoinHorizontal(lipgloss.Top, focusedTableStyle.Render(m.table.View()), modelStyle.Render(input)+"\n")
+	unfocusedTable := lipgloss.JoinHorizontal(lipgloss.Top, baseTableStyle.Render(m.table.View()), focusedModelStyle.Render(input)+"\n")
+
+	if m.state == detailView {
+		return lipgloss.JoinHorizontal(lipgloss.Top, baseTableStyle.Render(m.table.View()), getDetailUI(m)+"\n")
+	}
+
+	if m.state == editView {
+		return lipgloss.JoinHorizontal(lipgloss.Top, baseTableStyle.Render(m.table.View()), getEditUI(m)+"\n")
+	}
+
+	if m.state == confirmDeleteView {
+		return lipgloss.JoinHorizontal(lipgloss.Top, baseTableStyle.Render(m.table.View()), getConfirmDeleteUI(m)+"\n")
+	}
 
 	if m.state == tableView {
 		return focusedTable
@@ -275,93 +341,37 @@ func getTableUI(m mainModel) string {
 }
 
 func getInputUI(m mainModel) string {
-	if m.currentTab != 1 {
+	if m.currentTab != inventoryTab {
 		return ""
 	}
 
-	tableHelperText := tipContainerStyle.Render("tab: focus next • enter: create new item • q: exit")
-	inputHelperText := tipContainerStyle.Render("tab: focus next • enter: view entry • q: exit")
+	tableHelperText := tipContainerStyle.Render("tab: focus next • ↑/↓: category • enter: create new item • q: exit")
+	inputHelperText := tipContainerStyle.Render("tab: focus next • enter: view • e: edit • d: delete • [n]+/-: count\nc/C: filter/group • esc: clear • u: undo • ctrl+r: redo\n" + inventoryViewLabel(m))
+	if m.countPrefix != "" {
+		inputHelperText = tipContainerStyle.Render("adjust by " + m.countPrefix + " • +: add • -: use • any other key: cancel")
+	}
 	focusedInput := lipgloss.JoinVertical(lipgloss.Top, lipgloss.NewStyle().PaddingTop(1).Render(), tableHelperText)
 	unfocusedInput := lipgloss.JoinVertical(lipgloss.Top, lipgloss.NewStyle().PaddingTop(1).Render(), inputHelperText)
 
-	if m.state == tableView {
-		return unfocusedInput
+	if m.state == detailView {
+		detailHelperText := tipContainerStyle.Render("esc/tab: back to table • q: exit")
+		return lipgloss.JoinVertical(lipgloss.Top, lipgloss.NewStyle().PaddingTop(1).Render(), detailHelperText)
 	}
-	return focusedInput
-}
 
-func getListUI(m mainModel) string {
-	if m.currentTab != 2 {
-		return ""
+	if m.state == confirmDeleteView {
+		confirmHelperText := tipContainerStyle.Render("y: delete item • n/esc: keep it")
+		return lipgloss.JoinVertical(lipgloss.Top, lipgloss.NewStyle().PaddingTop(1).Render(), confirmHelperText)
 	}
 
-	titleStyle := lipgloss.NewStyle().
-		PaddingTop(2).
-		MarginLeft(6).
-		Height(2).
-		Bold(true).Foreground(theme.blue).
-		Render("Grocery List")
-
-	descriptionStyle := lipgloss.NewStyle().
-		Bold(true).PaddingTop(3).
-		Foreground(theme.lavender).
-		MarginLeft(2).
-		Render("Coming soon...")
-
-	line := lipgloss.NewStyle().
-		BorderForeground(theme.pink).
-		BorderTop(true).
-		BorderStyle(lipgloss.NormalBorder()).
-		PaddingTop(-1).
-		Width(50).
-		MarginLeft(6).Render()
-
-	spacer := lipgloss.NewStyle().
-		Height(3).Render(" ")
-
-	homeHelperText := tipContainerStyle.MarginLeft(6).Width(60).Padding(1).Render("i: go to inventory • g: go to list • s: go to settings")
-
-	return lipgloss.JoinVertical(lipgloss.Top, lipgloss.JoinHorizontal(lipgloss.Center, titleStyle, descriptionStyle), line, spacer, homeHelperText, spacer)
-}
-
-func getSettingsUI(m mainModel) string {
-	if m.currentTab != 3 {
-		return ""
+	if m.state == editView {
+		editHelperText := tipContainerStyle.Render("tab/↑/↓: next field • ←/→: pick an option • enter: save • esc: cancel")
+		return lipgloss.JoinVertical(lipgloss.Top, lipgloss.NewStyle().PaddingTop(1).Render(), editHelperText)
 	}
 
-	titleStyle := lipgloss.NewStyle().
-		PaddingTop(2).
-		MarginLeft(6).
-		Height(2).
-		Bold(true).Foreground(theme.blue).
-		Render("Chef!")
-
-	descriptionStyle := lipgloss.NewStyle().
-		Bold(true).PaddingTop(3).
-		Foreground(theme.lavender).
-		MarginLeft(2).
-		Render("Your new inventory cli tool")
-
-	line := lipgloss.NewStyle().
-		BorderForeground(theme.pink).
-		BorderTop(true).
-		BorderStyle(lipgloss.NormalBorder()).
-		PaddingTop(-1).
-		Width(50).
-		MarginLeft(6).Render()
-
-	bodyStyle := lipgloss.NewStyle().
-		Bold(true).PaddingTop(2).
-		Foreground(theme.lavender).
-		MarginLeft(6).
-		Render("Settings coming soon...\n\nEventually you'll be able to wipe your list.")
-
-	spacer := lipgloss.NewStyle().
-		Height(3).Render(" ")
-
-	homeHelperText := tipContainerStyle.MarginLeft(6).Width(60).Padding(1).Render("i: go to inventory • g: go to list • s: go to settings")
-
-	return lipgloss.JoinVertical(lipgloss.Top, lipgloss.JoinHorizontal(lipgloss.Center, titleStyle, descriptionStyle), line, bodyStyle, spacer, homeHelperText, spacer)
+	if m.state == tableView {
+		return unfocusedInput
+	}
+	return focusedInput
 }
 
 func (m mainModel) View() string {
@@ -378,11 +388,39 @@ func (m mainModel) View() string {
 	s += getListUI(m)
 
 	// Tab 4 UI
+	s += getLocationsUI(m)
+
+	// Tab 5 UI
+	s += getTrashUI(m)
+
+	// Tab 6 UI
 	s += getSettingsUI(m)
 
+	if m.status != "" {
+		s += "\n" + lipgloss.NewStyle().Foreground(theme.lavender).MarginLeft(6).Render(m.status) + "\n"
+	}
+
+	if m.err != nil {
+		s += "\n" + lipgloss.NewStyle().Foreground(theme.pink).MarginLeft(6).Render(m.err.Error()) + "\n"
+	}
+
 	return s
 }
 
+// isTyping reports whether the open tab has a focused text input or prompt,
+// in which case single letter shortcuts shouldn't switch tabs.
+func (m mainModel) isTyping() bool {
+	switch m.currentTab {
+	case inventoryTab:
+		return m.textInput.Focused() || m.state == editView || m.state == confirmDeleteView
+	case listTab:
+		return m.listInput.Focused()
+	case locationsTab:
+		return m.locationInput.Focused() || m.confirmingLocationDelete
+	}
+	return false
+}
+
 // Add initial actions on mount.
 func (m mainModel) Init() tea.Cmd {
 	return tea.Batch(m.textInput.Focus(), textinput.Blink) // no batch?
@@ -393,23 +431,54 @@ func (m mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		m.err = nil
+		m.status = ""
+
 		switch msg.String() {
-		case "ctrl+c", "q":
+		case "ctrl+c":
 			return m, tea.Quit
+		case "q":
+			if !m.isTyping() {
+				return m, tea.Quit
+			}
 		case "enter":
-			if m.state == inputView {
-				item := m.textInput.Value()
-				db.CreateGroceryItem(item)
-				rows := m.table.Rows()
-				id := len(m.table.Rows()) + 1
-				row := []string{fmt.Sprint(id), item, fmt.Sprint(1)}
-				rows = append(rows, row)
-				m.table.SetRows(rows)
-				m.table.GotoBottom()
+			if m.currentTab == inventoryTab && m.state == inputView {
+				line, err := ingredient.Parse(m.textInput.Value())
+				if err != nil {
+					m.err = err
+					break
+				}
+				if line.Quantity == 0 {
+					line.Quantity = float64(m.settings.defaultCount)
+				}
+				before, _ := m.store.GetGroceryItemByName(line.Name)
+				item, created, err := m.store.UpsertGroceryItem(db.GroceryItem{
+					Name:       line.Name,
+					Count:      line.Quantity,
+					Unit:       line.Unit,
+					CategoryID: categoryIDAt(m, m.addCategory),
+					LocationID: m.locationFilter,
+				})
+				if err != nil {
+					m.err = err
+					break
+				}
+				m = reloadInventory(m, item.ID)
+				if created {
+					m = pushUndo(m, undoStep{kind: undoCreate, description: "adding " + item.Name, after: item})
+				} else {
+					m = pushUndo(m, undoStep{kind: undoUpdate, description: "restocking " + item.Name, before: before, after: item})
+					m.status = fmt.Sprintf("%s was already in the inventory, count is now %s.", item.Name, formatQuantity(item.Count, item.Unit))
+				}
 				m.textInput.Reset()
 				m.textInput.Cursor.SetMode(cursor.New().Mode())
+			} else if m.currentTab == inventoryTab && m.state == tableView {
+				m = openDetail(m)
 			}
 		case "tab":
+			if m.currentTab != inventoryTab || m.state == editView || m.state == confirmDeleteView {
+				break
+			}
 			if m.state == tableView {
 				m.state = inputView
 				m.table.Blur()
@@ -421,48 +490,131 @@ func (m mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 		case "shift+tab":
-			switch m.currentTab {
-			case 0:
-				m.currentTab = 1
-			case 1:
-				m.currentTab = 2
-			case 2:
-				m.currentTab = 3
-			case 3:
-				m.currentTab = 0
-			default:
-				m.currentTab = 0
-			}
+			m.currentTab = (m.currentTab + 1) % len(tabs)
 
 		case "h":
-			if !m.textInput.Focused() {
-				m.currentTab = 0
+			if !m.isTyping() {
+				m.currentTab = homeTab
 			}
 
 		case "i":
-			if !m.textInput.Focused() {
-				m.currentTab = 1
+			if !m.isTyping() {
+				m.currentTab = inventoryTab
 			}
 
 		case "g":
-			if !m.textInput.Focused() {
-				m.currentTab = 2
+			if !m.isTyping() {
+				m.currentTab = listTab
+			}
+
+		case "l":
+			if !m.isTyping() {
+				m.currentTab = locationsTab
+			}
+
+		case "t":
+			if !m.isTyping() {
+				m.currentTab = trashTab
 			}
+
 		case "s":
-			if !m.textInput.Focused() {
-				m.currentTab = 3
+			if !m.isTyping() {
+				m.currentTab = settingsTab
+			}
+
+		case "e":
+			if m.currentTab == inventoryTab && m.state == tableView {
+				m, cmd = openEdit(m)
+				return m, cmd
+			}
+
+		case "d", "delete":
+			if m.currentTab == inventoryTab && m.state == tableView {
+				m = openDelete(m)
+				return m, nil
+			}
+
+		case "u":
+			if m.currentTab == inventoryTab && m.state == tableView {
+				m = undo(m)
+				return m, nil
+			}
+
+		case "ctrl+r":
+			if m.currentTab == inventoryTab && m.state == tableView {
+				m = redo(m)
+				return m, nil
+			}
+
+		case "c":
+			if m.currentTab == inventoryTab && m.state == tableView {
+				m = cycleCategoryFilter(m)
+				return m, nil
 			}
+
+		case "C":
+			if m.currentTab == inventoryTab && m.state == tableView {
+				m = toggleGrouping(m)
+				return m, nil
+			}
+
+		case "esc":
+			if m.currentTab == inventoryTab && m.state == tableView {
+				m = clearFilters(m)
+				return m, nil
+			}
+		}
+
+		if m.currentTab == listTab {
+			m, cmd = updateList(m, msg)
+			return m, cmd
+		}
+
+		if m.currentTab == locationsTab {
+			m, cmd = updateLocations(m, msg)
+			return m, cmd
+		}
+
+		if m.currentTab == trashTab {
+			m, cmd = updateTrash(m, msg)
+			return m, cmd
 		}
 
+		if m.currentTab == settingsTab {
+			m, cmd = updateSettings(m, msg)
+			return m, cmd
+		}
+		m.confirmingWipe = false
+
 		switch m.state {
 		// update whichever model is focused
 		case inputView:
-			m.textInput, cmd = m.textInput.Update(msg)
+			switch msg.String() {
+			case "up":
+				m.addCategory = cycleCategory(m, m.addCategory, -1)
+			case "down":
+				m.addCategory = cycleCategory(m, m.addCategory, 1)
+			default:
+				m.textInput, cmd = m.textInput.Update(msg)
+				cmds = append(cmds, cmd)
+				cmds = append(cmds, textinput.Blink)
+			}
+		case editView:
+			m, cmd = updateEdit(m, msg)
 			cmds = append(cmds, cmd)
-			cmds = append(cmds, textinput.Blink)
+		case confirmDeleteView:
+			m = updateConfirmDelete(m, msg)
+		case detailView:
+			if msg.String() == "esc" {
+				m.state = tableView
+				m.table.Focus()
+			}
 		case tableView:
-			m.table, cmd = m.table.Update(msg)
-			cmds = append(cmds, cmd)
+			var handled bool
+			if m, handled = updateCount(m, msg); !handled {
+				m.table, cmd = m.table.Update(msg)
+				cmds = append(cmds, cmd)
+			}
 		default:
 			m.table, cmd = m.table.Update(msg)
 			cmds = append(cmds, cmd)
@@ -473,39 +625,66 @@ func (m mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 // Note: This is set up to add future commands.
-func parseCommand(command string) (tea.Model, error) {
-	switch command {
-	case "init":
-		model, err := tea.NewProgram(newModel()).Run()
-		return model, err
-	case "help":
-		model, err := tea.NewProgram(newModel()).Run()
-		return model, err
-	default:
-		model, err := tea.NewProgram(newModel()).Run()
-		return model, err
+func runTUI(store db.InventoryStore) error {
+	m, err := newModel(store)
+	if err != nil {
+		return err
 	}
+
+	_, err = tea.NewProgram(m).Run()
+	return err
 }
 
-func main() {
-	log.Info("Starting application...")
+// run dispatches the command line and returns the process exit code.
+func run(args []string) int {
+	global, dbPath := globalFlags()
+	if err := global.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printUsage(os.Stdout)
+			return exitOK
+		}
+		log.Error(err)
+		printUsage(os.Stderr)
+		return exitUsage
+	}
 
-	db.InitDatabaseConnection()
+	args = global.Args()
+	if len(args) == 0 {
+		printUsage(os.Stderr)
+		return exitUsage
+	}
 
-	argsAfterCommandName := os.Args[1:]
+	c, found := findCommand(args[0])
+	if !found {
+		log.Errorf("Unknown command %q.", args[0])
+		printUsage(os.Stderr)
+		return exitUsage
+	}
 
-	if false {
-		log.Error("Please invoke with a command. \n\n\t`$ go run main.go <command>`\n")
-		os.Exit(1)
+	path, err := db.ResolvePath(*dbPath)
+	if err != nil {
+		log.Error(err)
+		return exitError
 	}
 
-	for _, element := range argsAfterCommandName {
-		_, err := parseCommand(element)
-		if err != nil {
-			log.Error(err)
-			os.Exit(1)
-		}
+	err = dispatch(c, path, args[1:])
+
+	var usage usageError
+	switch {
+	case errors.As(err, &usage):
+		log.Error(err)
+		return exitUsage
+	case err != nil:
+		log.Error(err)
+		return exitError
 	}
 
-	log.Info("Program terminated.")
+	return exitOK
+}
+
+func main() {
+	log.Debug("Starting application...")
+	code := run(os.Args[1:])
+	log.Debug("Program terminated.")
+	os.Exit(code)
 }
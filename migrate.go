@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	db "github.com/lundjrl/go-bubble-tea-playground/shared/database"
+)
+
+// migrateCommand handles `chef migrate status|up|down` for the database at
+// path. It runs before the store is opened, since opening applies migrations.
+func migrateCommand(path string, args []string) error {
+	yes := false
+	if i := slices.Index(args, "--yes"); i >= 0 {
+		yes = true
+		args = slices.Delete(slices.Clone(args), i, i+1)
+	}
+
+	if len(args) != 1 {
+		return usagef("Please say status, up or down, like `chef migrate status`.")
+	}
+
+	migrator, err := db.OpenMigrator(path)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "status":
+		statuses, err := migrator.Status()
+		fmt.Printf("Database %s\n\n", path)
+		for _, status := range statuses {
+			state := "pending"
+			if status.AppliedAt != nil {
+				state = "applied " + formatTime(*status.AppliedAt)
+			}
+			fmt.Printf("  %3d  %-30s %s\n", status.Version, status.Name, state)
+		}
+		return err
+	case "up":
+		applied, err := migrator.Up()
+		for _, migration := range applied {
+			fmt.Printf("Applied %d (%s)\n", migration.Version, migration.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("Already up to date.")
+		}
+		return err
+	case "down":
+		// Reverting drops whatever the migration added, so say what goes
+		// before doing it.
+		if !yes {
+			migration, err := migrator.LastApplied()
+			if err != nil {
+				return err
+			}
+			return usagef("Reverting %d (%s) deletes %s. Run `chef migrate down --yes` to go ahead.", migration.Version, migration.Name, migration.Loses)
+		}
+
+		migration, err := migrator.Down()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Reverted %d (%s). Chef applies it again the next time it opens the database.\n", migration.Version, migration.Name)
+		return nil
+	}
+
+	return usagef("Unknown migrate command %q. Use status, up or down.", args[0])
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	db "github.com/lundjrl/go-bubble-tea-playground/shared/database"
+)
+
+// appSettings holds the persisted settings in their typed form.
+type appSettings struct {
+	defaultCount  int
+	confirmDelete bool
+	theme         string
+	expiryWindow  int
+	trashDays     int
+	autoBackup    bool
+	backupKeep    int
+	backupDir     string
+}
+
+// settingsField identifies a row on the settings tab.
+type settingsField int
+
+const (
+	defaultCountField settingsField = iota
+	confirmDeleteField
+	themeField
+	expiryWindowField
+	trashDaysField
+	autoBackupField
+	backupKeepField
+	wipeInventoryField
+	wipeListField
+)
+
+var settingsFields = []settingsField{
+	defaultCountField,
+	confirmDeleteField,
+	themeField,
+	expiryWindowField,
+	trashDaysField,
+	autoBackupField,
+	backupKeepField,
+	wipeInventoryField,
+	wipeListField,
+}
+
+func loadSettings(values map[string]string) appSettings {
+	settings := appSettings{
+		defaultCount:  max(0, db.SettingInt(values, db.SettingDefaultCount, 1)),
+		confirmDelete: db.SettingBool(values, db.SettingConfirmDelete, true),
+		theme:         values[db.SettingTheme],
+		expiryWindow:  max(0, db.SettingInt(values, db.SettingExpiryWindow, 7)),
+		trashDays:     max(0, db.SettingInt(values, db.SettingTrashDays, 30)),
+		autoBackup:    db.SettingBool(values, db.SettingAutoBackup, false),
+		backupKeep:    max(1, db.SettingInt(values, db.SettingBackupKeep, 7)),
+		backupDir:     values[db.SettingBackupDir],
+	}
+
+	if _, ok := themes[settings.theme]; !ok {
+		settings.theme = themeNames[0]
+	}
+
+	return settings
+}
+
+func (f settingsField) label() string {
+	switch f {
+	case defaultCountField:
+		return "Default item count"
+	case confirmDeleteField:
+		return "Confirm before delete"
+	case themeField:
+		return "Theme"
+	case expiryWindowField:
+		return "Expiring soon window"
+	case trashDaysField:
+		return "Empty trash after"
+	case autoBackupField:
+		return "Back up on start"
+	case backupKeepField:
+		return "Backups to keep"
+	case wipeInventoryField:
+		return "Wipe inventory"
+	case wipeListField:
+		return "Wipe grocery list"
+	}
+	return ""
+}
+
+func (f settingsField) value(s appSettings) string {
+	switch f {
+	case defaultCountField:
+		return fmt.Sprintf("‹ %d ›", s.defaultCount)
+	case confirmDeleteField:
+		if s.confirmDelete {
+			return "on"
+		}
+		return "off"
+	case themeField:
+		return fmt.Sprintf("‹ %s ›", s.theme)
+	case expiryWindowField:
+		return fmt.Sprintf("‹ %d days ›", s.expiryWindow)
+	case trashDaysField:
+		if s.trashDays == 0 {
+			return "‹ never ›"
+		}
+		return fmt.Sprintf("‹ %d days ›", s.trashDays)
+	case autoBackupField:
+		if s.autoBackup {
+			return "on"
+		}
+		return "off"
+	case backupKeepField:
+		return fmt.Sprintf("‹ %d ›", s.backupKeep)
+	}
+	return ""
+}
+
+func getSettingsRowsUI(m mainModel) string {
+	lines := []string{}
+
+	for i, field := range settingsFields {
+		line := fmt.Sprintf("%-24s %s", field.label(), field.value(m.settings))
+
+		if i == m.settingsCursor {
+			lines = append(lines, listCursorStyle.Render("> "+line))
+		} else {
+			lines = append(lines, listItemStyle.Render("  "+line))
+		}
+	}
+
+	path := m.store.Path()
+	if path == "" {
+		path = "in memory"
+	}
+	lines = append(lines, "", listItemStyle.Foreground(theme.lavender).Render(fmt.Sprintf("  %-24s %s", "Database", path)))
+	if m.store.Path() != "" {
+		lines = append(lines, listItemStyle.Foreground(theme.lavender).Render(fmt.Sprintf("  %-24s %s", "Backups", backupDir(m.store, m.settings))))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func getSettingsUI(m mainModel) string {
+	if m.currentTab != settingsTab {
+		return ""
+	}
+
+	titleStyle := lipgloss.NewStyle().
+		PaddingTop(2).
+		MarginLeft(6).
+		Height(2).
+		Bold(true).Foreground(theme.blue).
+		Render("Settings")
+
+	descriptionStyle := lipgloss.NewStyle().
+		Bold(true).PaddingTop(3).
+		Foreground(theme.lavender).
+		MarginLeft(2).
+		Render("Make chef your own")
+
+	line := lipgloss.NewStyle().
+		BorderForeground(theme.pink).
+		BorderTop(true).
+		BorderStyle(lipgloss.NormalBorder()).
+		PaddingTop(-1).
+		Width(50).
+		MarginLeft(6).Render()
+
+	spacer := lipgloss.NewStyle().
+		Height(1).Render(" ")
+
+	settingsHelperText := tipContainerStyle.MarginLeft(6).Width(60).Padding(1).Render("↑/↓: select • ←/→: change • enter: toggle or run • q: exit")
+	if m.confirmingWipe {
+		prompt := fmt.Sprintf("%s? This can't be undone. y: yes • n: no", settingsFields[m.settingsCursor].label())
+		if settingsFields[m.settingsCursor] == wipeInventoryField {
+			prompt = "Wipe inventory? Every item moves to the Trash. y: yes • n: no"
+		}
+		settingsHelperText = tipContainerStyle.MarginLeft(6).Width(60).Padding(1).BorderForeground(theme.pink).Render(prompt)
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Top, lipgloss.JoinHorizontal(lipgloss.Center, titleStyle, descriptionStyle), line, spacer, getSettingsRowsUI(m), settingsHelperText, spacer)
+}
+
+// cycleTheme returns the theme name step places away from the current one.
+func cycleTheme(current string, step int) string {
+	for i, name := range themeNames {
+		if name == current {
+			return themeNames[(i+step+len(themeNames))%len(themeNames)]
+		}
+	}
+	return themeNames[0]
+}
+
+// changeSetting moves the selected setting one step in direction and saves it.
+func changeSetting(m mainModel, direction int) (mainModel, error) {
+	switch settingsFields[m.settingsCursor] {
+	case defaultCountField:
+		count := max(0, m.settings.defaultCount+direction)
+		if err := m.store.SetSetting(db.SettingDefaultCount, strconv.Itoa(count)); err != nil {
+			return m, err
+		}
+		m.settings.defaultCount = count
+	case confirmDeleteField:
+		confirm := !m.settings.confirmDelete
+		if err := m.store.SetSetting(db.SettingConfirmDelete, strconv.FormatBool(confirm)); err != nil {
+			return m, err
+		}
+		m.settings.confirmDelete = confirm
+	case themeField:
+		name := cycleTheme(m.settings.theme, direction)
+		if err := m.store.SetSetting(db.SettingTheme, name); err != nil {
+			return m, err
+		}
+		m.settings.theme = name
+		setTheme(name)
+		m.table.SetStyles(tableStyles())
+	case expiryWindowField:
+		days := max(0, m.settings.expiryWindow+direction)
+		if err := m.store.SetSetting(db.SettingExpiryWindow, strconv.Itoa(days)); err != nil {
+			return m, err
+		}
+		m.settings.expiryWindow = days
+	case trashDaysField:
+		days := max(0, m.settings.trashDays+direction)
+		if err := m.store.SetSetting(db.SettingTrashDays, strconv.Itoa(days)); err != nil {
+			return m, err
+		}
+		// The new retention applies the next time chef opens, so stepping
+		// past a short one on the way to "never" doesn't empty the trash.
+		m.settings.trashDays = days
+	case autoBackupField:
+		enabled := !m.settings.autoBackup
+		if err := m.store.SetSetting(db.SettingAutoBackup, strconv.FormatBool(enabled)); err != nil {
+			return m, err
+		}
+		m.settings.autoBackup = enabled
+	case backupKeepField:
+		keep := max(1, m.settings.backupKeep+direction)
+		if err := m.store.SetSetting(db.SettingBackupKeep, strconv.Itoa(keep)); err != nil {
+			return m, err
+		}
+		m.settings.backupKeep = keep
+	}
+
+	return m, nil
+}
+
+func wipe(m mainModel) (mainModel, error) {
+	switch settingsFields[m.settingsCursor] {
+	case wipeInventoryField:
+		if err := m.store.WipeGroceryItems(); err != nil {
+			return m, err
+		}
+		m = clearUndo(reloadInventory(m, 0))
+	case wipeListField:
+		if err := m.store.WipeShoppingList(); err != nil {
+			return m, err
+		}
+		m.groceryList = nil
+		m.listCursor = 0
+	}
+
+	return m, nil
+}
+
+// updateSettings handles key presses while the settings tab is open.
+func updateSettings(m mainModel, msg tea.KeyMsg) (mainModel, tea.Cmd) {
+	var err error
+
+	if m.confirmingWipe {
+		m.confirmingWipe = false
+		if msg.String() == "y" {
+			m, err = wipe(m)
+			m.err = err
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "up", "k":
+		if m.settingsCursor > 0 {
+			m.settingsCursor--
+		}
+	case "down", "j":
+		if m.settingsCursor < len(settingsFields)-1 {
+			m.settingsCursor++
+		}
+	case "left", "-":
+		m, err = changeSetting(m, -1)
+	case "right", "+":
+		m, err = changeSetting(m, 1)
+	case "enter", " ":
+		switch settingsFields[m.settingsCursor] {
+		case wipeInventoryField, wipeListField:
+			m.confirmingWipe = true
+		default:
+			m, err = changeSetting(m, 1)
+		}
+	}
+
+	m.err = err
+	return m, nil
+}
@@ -2,22 +2,40 @@ package database
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
-func InitDatabaseConnection() {
-	var err error
+// openSQLite opens the SQLite database at path, creating it and its directory
+// if needed.
+func openSQLite(path string) (*gorm.DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create database directory: %w", err)
+	}
 
-	DBConn, err = gorm.Open(sqlite.Open("app.db"))
+	db, err := gorm.Open(sqlite.Open(path))
 
 	if err != nil {
-		panic("failed to connect to database")
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
-	fmt.Println("Database connection started")
 
-	DBConn.AutoMigrate(&GroceryItem{})
+	return db, nil
+}
+
+// OpenGormStore opens the database at path and applies any pending
+// migrations. It refuses databases written by a newer chef.
+func OpenGormStore(path string) (*GormStore, error) {
+	db, err := openSQLite(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := migrateUp(db); err != nil {
+		return nil, err
+	}
 
-	fmt.Println("Database Migrated")
+	return &GormStore{db: db, path: path}, nil
 }
@@ -0,0 +1,160 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// backupRetries is how many times a backup step waits out another connection
+// holding the database before giving up.
+const backupRetries = 50
+
+// BackupTo copies the database to dest with SQLite's online backup API, so
+// the copy is consistent even while the TUI is writing. dest only appears
+// once the copy is complete.
+func (s *GormStore) BackupTo(dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	temp := dest + ".partial"
+	defer os.Remove(temp)
+
+	target, err := sql.Open("sqlite3", temp)
+	if err != nil {
+		return err
+	}
+
+	source, err := s.db.DB()
+	if err != nil {
+		target.Close()
+		return err
+	}
+
+	err = copyDatabase(target, source)
+	if closeErr := target.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(temp, dest)
+}
+
+// RestoreFrom overwrites the database with the backup at src, then brings it
+// up to the current schema. Other connections, like an open TUI, see the
+// restored data on their next read.
+func (s *GormStore) RestoreFrom(src string) error {
+	if _, err := os.Stat(src); err != nil {
+		return fmt.Errorf("There's no backup at %s.", src)
+	}
+
+	// mode=ro keeps a mistyped path from being created as an empty database.
+	backup, err := gorm.Open(sqlite.Open("file:"+url.PathEscape(src)+"?mode=ro"), &gorm.Config{})
+	if err != nil {
+		return fmt.Errorf("%s can't be restored: %w", src, err)
+	}
+
+	source, err := backup.DB()
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	if err := checkBackup(backup); err != nil {
+		return fmt.Errorf("%s can't be restored. %w", src, err)
+	}
+
+	target, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+
+	if err := copyDatabase(target, source); err != nil {
+		return err
+	}
+
+	_, err = migrateUp(s.db)
+	return err
+}
+
+// checkBackup makes sure a file is a chef database this build can read,
+// without writing to it.
+func checkBackup(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&GroceryItem{}) {
+		return errors.New("It isn't a chef database.")
+	}
+
+	if !db.Migrator().HasTable(&schemaMigration{}) {
+		return nil
+	}
+
+	var applied []schemaMigration
+	if err := db.Order("version").Find(&applied).Error; err != nil {
+		return err
+	}
+
+	return checkSchemaVersion(applied)
+}
+
+// copyDatabase replaces everything in target with the contents of source,
+// page by page, waiting whenever another connection has the database busy.
+func copyDatabase(target *sql.DB, source *sql.DB) error {
+	ctx := context.Background()
+
+	targetConn, err := target.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer targetConn.Close()
+
+	sourceConn, err := source.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer sourceConn.Close()
+
+	return targetConn.Raw(func(targetDriver any) error {
+		return sourceConn.Raw(func(sourceDriver any) error {
+			to, ok := targetDriver.(*sqlite3.SQLiteConn)
+			from, ok2 := sourceDriver.(*sqlite3.SQLiteConn)
+			if !ok || !ok2 {
+				return errors.New("Backups need the sqlite3 driver.")
+			}
+
+			backup, err := to.Backup("main", from, "main")
+			if err != nil {
+				return err
+			}
+
+			for retries := 0; ; {
+				done, err := backup.Step(-1)
+
+				var sqliteErr sqlite3.Error
+				if errors.As(err, &sqliteErr) && (sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked) && retries < backupRetries {
+					retries++
+					time.Sleep(100 * time.Millisecond)
+					continue
+				}
+				if err != nil {
+					backup.Finish()
+					return err
+				}
+				if done {
+					return backup.Finish()
+				}
+			}
+		})
+	})
+}
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type Category struct {
+	Model
+	Name string `gorm:"uniqueIndex" json:"name"`
+}
+
+// defaultCategories are created the first time chef opens a database.
+var defaultCategories = []string{
+	"produce",
+	"dairy",
+	"meat",
+	"bakery",
+	"baking",
+	"pantry",
+	"frozen",
+	"beverages",
+	"household",
+}
+
+func (s *GormStore) GetCategories() ([]Category, error) {
+	db := s.db
+
+	var categories []Category
+	result := db.Order("id").Find(&categories)
+
+	return categories, result.Error
+}
+
+func (s *GormStore) CreateCategory(categoryName string) (Category, error) {
+	name := normalizeName(categoryName)
+
+	if len(name) <= 0 {
+		return Category{}, errors.New("Please type a category.")
+	}
+
+	db := s.db
+
+	var existing int64
+	if err := db.Model(&Category{}).Where("name = ?", name).Count(&existing).Error; err != nil {
+		return Category{}, err
+	}
+
+	if existing > 0 {
+		return Category{}, errors.New("There's already a category with that name.")
+	}
+
+	category := Category{Name: name}
+	result := db.Create(&category)
+
+	return category, result.Error
+}
+
+func seedCategories(db *gorm.DB) error {
+	var count int64
+	if err := db.Model(&Category{}).Count(&count).Error; err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return nil
+	}
+
+	categories := []Category{}
+	for _, name := range defaultCategories {
+		categories = append(categories, Category{Name: name})
+	}
+
+	return db.Create(&categories).Error
+}
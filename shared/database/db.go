@@ -2,4 +2,13 @@ package database
 
 import "gorm.io/gorm"
 
-var DBConn *gorm.DB
+// GormStore is the InventoryStore chef uses day to day, kept in a SQLite
+// file through gorm.
+type GormStore struct {
+	db   *gorm.DB
+	path string
+}
+
+func (s *GormStore) Path() string {
+	return s.path
+}
@@ -0,0 +1,111 @@
+package database
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// EventKind says what happened to an item.
+type EventKind string
+
+const (
+	EventAdd     EventKind = "add"
+	EventConsume EventKind = "consume"
+	EventAdjust  EventKind = "adjust"
+	EventEdit    EventKind = "edit"
+	EventDelete  EventKind = "delete"
+	EventRestore EventKind = "restore"
+	EventUndo    EventKind = "undo"
+	EventRedo    EventKind = "redo"
+)
+
+// InventoryEvent is one entry in an item's history. Delta is the change in
+// Count, in the item's unit at the time.
+type InventoryEvent struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	GroceryItemID uint      `gorm:"index" json:"groceryItemId"`
+	Kind          EventKind `json:"kind"`
+	Delta         float64   `json:"delta"`
+	Unit          string    `json:"unit"`
+	Note          string    `json:"note"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// recordEvent writes an event inside the transaction making the change, so
+// the history can't drift from the inventory.
+func recordEvent(tx *gorm.DB, item GroceryItem, kind EventKind, delta float64, note string) error {
+	return tx.Create(&InventoryEvent{
+		GroceryItemID: item.ID,
+		Kind:          kind,
+		Delta:         delta,
+		Unit:          item.Unit,
+		Note:          note,
+	}).Error
+}
+
+func (s *GormStore) GetInventoryEvents(itemID uint) ([]InventoryEvent, error) {
+	db := s.db
+
+	var events []InventoryEvent
+	result := db.Where("grocery_item_id = ?", itemID).Order("created_at DESC, id DESC").Find(&events)
+
+	return events, result.Error
+}
+
+// quantityText renders a quantity for event notes, e.g. "500 ml".
+func quantityText(count float64, unit string) string {
+	return strconv.FormatFloat(count, 'f', -1, 64) + " " + unitName(unit)
+}
+
+// editNote describes what an edit changed, e.g. "renamed from milk, unit".
+func editNote(before GroceryItem, after GroceryItem) string {
+	changes := []string{}
+
+	if before.Name != after.Name {
+		changes = append(changes, "renamed from "+before.Name)
+	}
+	if before.Count != after.Count {
+		changes = append(changes, "count")
+	}
+	if before.Unit != after.Unit {
+		changes = append(changes, "unit")
+	}
+	if !sameID(before.CategoryID, after.CategoryID) {
+		changes = append(changes, "category")
+	}
+	if !sameID(before.LocationID, after.LocationID) {
+		changes = append(changes, "location")
+	}
+	if !sameTime(before.ExpiresAt, after.ExpiresAt) {
+		changes = append(changes, "expiry")
+	}
+	if before.MinCount != after.MinCount {
+		changes = append(changes, "min count")
+	}
+
+	if len(changes) == 0 {
+		return "saved without changes"
+	}
+
+	return "edited " + strings.Join(changes, ", ")
+}
+
+// revertNote describes an undo or redo by the edit it replayed, e.g.
+// "undone: edited count".
+func revertNote(kind EventKind, note string) string {
+	if kind == EventUndo {
+		return "undone: " + note
+	}
+	return "redone: " + note
+}
+
+func sameID(a, b *uint) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+func sameTime(a, b *time.Time) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && a.Equal(*b))
+}
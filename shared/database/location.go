@@ -0,0 +1,157 @@
+package database
+
+import (
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Location is a place items are stored. Locations nest through ParentID, so
+// "Top shelf" can sit in "Fridge", which sits in "Kitchen".
+type Location struct {
+	Model
+	Name     string     `json:"name"`
+	ParentID *uint      `gorm:"index" json:"parentId"`
+	Parent   *Location  `json:"-"`
+	Children []Location `gorm:"foreignKey:ParentID" json:"-"`
+}
+
+func (s *GormStore) GetLocations() ([]Location, error) {
+	db := s.db
+
+	var locations []Location
+	result := db.Order("name").Find(&locations)
+
+	return locations, result.Error
+}
+
+func (s *GormStore) CreateLocation(locationName string, parentID *uint) (Location, error) {
+	name := strings.Join(strings.Fields(locationName), " ")
+
+	if len(name) <= 0 {
+		return Location{}, errors.New("Please type a location.")
+	}
+
+	db := s.db
+
+	// Names are compared ignoring case, the way they're looked up, so
+	// "Fridge" and "fridge" can't both sit in the same place.
+	var siblings int64
+	result := db.Model(&Location{}).Where("parent_id IS ? AND LOWER(name) = LOWER(?)", parentID, name).Count(&siblings)
+
+	if result.Error != nil {
+		return Location{}, result.Error
+	}
+
+	if siblings > 0 {
+		return Location{}, errors.New("There's already a location with that name here.")
+	}
+
+	location := Location{Name: name, ParentID: parentID}
+	result = db.Create(&location)
+
+	return location, result.Error
+}
+
+// DeleteLocation removes a location with no locations inside it. Items stored
+// there, trashed ones included, move up to its parent location.
+func (s *GormStore) DeleteLocation(id uint) error {
+	db := s.db
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var location Location
+		if err := tx.First(&location, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("There's no location with that id.")
+			}
+			return err
+		}
+
+		var children int64
+		if err := tx.Model(&Location{}).Where("parent_id = ?", id).Count(&children).Error; err != nil {
+			return err
+		}
+
+		if children > 0 {
+			return errors.New("Move or delete the locations inside this one first.")
+		}
+
+		// Trashed items move too, so restoring one doesn't bring back a
+		// location that's gone.
+		var items []GroceryItem
+		if err := tx.Unscoped().Where("location_id = ?", id).Find(&items).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Model(&GroceryItem{}).Where("location_id = ?", id).Update("location_id", location.ParentID).Error; err != nil {
+			return err
+		}
+
+		note := locationDeletedNote(location)
+		for _, item := range items {
+			if err := recordEvent(tx, item, EventEdit, 0, note); err != nil {
+				return err
+			}
+		}
+
+		return tx.Delete(&location).Error
+	})
+}
+
+// locationDeletedNote is the history note for items moved out of a deleted
+// location.
+func locationDeletedNote(location Location) string {
+	return "moved out of " + location.Name + ", which was deleted"
+}
+
+// LocationSubtree returns the IDs of root and every location nested under it.
+func LocationSubtree(locations []Location, root uint) map[uint]bool {
+	children := map[uint][]uint{}
+	for _, location := range locations {
+		if location.ParentID != nil {
+			children[*location.ParentID] = append(children[*location.ParentID], location.ID)
+		}
+	}
+
+	subtree := map[uint]bool{}
+	queue := []uint{root}
+
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+
+		if subtree[id] {
+			continue
+		}
+
+		subtree[id] = true
+		queue = append(queue, children[id]...)
+	}
+
+	return subtree
+}
+
+// LocationPath spells out where a location is, e.g. "House > Kitchen > Fridge".
+func LocationPath(locations []Location, id uint) string {
+	byID := map[uint]Location{}
+	for _, location := range locations {
+		byID[location.ID] = location
+	}
+
+	names := []string{}
+	seen := map[uint]bool{}
+
+	for next := &id; next != nil; {
+		location, ok := byID[*next]
+		if !ok || seen[location.ID] {
+			break
+		}
+
+		seen[location.ID] = true
+		names = append([]string{location.Name}, names...)
+		next = location.ParentID
+	}
+
+	return strings.Join(names, " > ")
+}
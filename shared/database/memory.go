@@ -0,0 +1,748 @@
+package database
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lundjrl/go-bubble-tea-playground/shared/units"
+	"gorm.io/gorm"
+)
+
+// MemoryStore is an InventoryStore that lives in memory and is lost on exit.
+// It follows the same rules as GormStore, so it can stand in for it when
+// chef is embedded or tested.
+type MemoryStore struct {
+	mu sync.Mutex
+
+	nextID       uint
+	items        []GroceryItem
+	events       []InventoryEvent
+	shoppingList []ShoppingListItem
+	categories   []Category
+	locations    []Location
+	settings     map[string]string
+}
+
+// NewMemoryStore returns an empty store with the default categories.
+func NewMemoryStore() *MemoryStore {
+	s := &MemoryStore{settings: map[string]string{}}
+
+	for _, name := range defaultCategories {
+		s.categories = append(s.categories, Category{Model: s.newModel(), Name: name})
+	}
+
+	return s
+}
+
+// Path is empty, there's no file behind a MemoryStore.
+func (s *MemoryStore) Path() string {
+	return ""
+}
+
+// newModel hands out the next ID. IDs are shared between tables, which is
+// fine as they're only ever compared within one.
+func (s *MemoryStore) newModel() Model {
+	s.nextID++
+	now := time.Now()
+	return Model{ID: s.nextID, CreatedAt: now, UpdatedAt: now}
+}
+
+func (s *MemoryStore) recordEvent(item GroceryItem, kind EventKind, delta float64, note string) {
+	s.nextID++
+	s.events = append(s.events, InventoryEvent{
+		ID:            s.nextID,
+		GroceryItemID: item.ID,
+		Kind:          kind,
+		Delta:         delta,
+		Unit:          item.Unit,
+		Note:          note,
+		CreatedAt:     time.Now(),
+	})
+}
+
+func (s *MemoryStore) GetInventoryEvents(itemID uint) ([]InventoryEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := []InventoryEvent{}
+	for i := len(s.events) - 1; i >= 0; i-- {
+		if s.events[i].GroceryItemID == itemID {
+			events = append(events, s.events[i])
+		}
+	}
+
+	return events, nil
+}
+
+// itemIndex finds a live item by ID, or returns -1.
+func (s *MemoryStore) itemIndex(id uint) int {
+	return slices.IndexFunc(s.items, func(item GroceryItem) bool {
+		return item.ID == id && !item.DeletedAt.Valid
+	})
+}
+
+func (s *MemoryStore) itemIndexByName(name string) int {
+	return slices.IndexFunc(s.items, func(item GroceryItem) bool {
+		return item.Name == name && !item.DeletedAt.Valid
+	})
+}
+
+// withRelations returns a copy of item with its category and location filled
+// in, like the Preloads in GormStore.
+func (s *MemoryStore) withRelations(item GroceryItem) GroceryItem {
+	item.Category = nil
+	item.Location = nil
+
+	if item.CategoryID != nil {
+		if i := slices.IndexFunc(s.categories, func(c Category) bool { return c.ID == *item.CategoryID }); i >= 0 {
+			category := s.categories[i]
+			item.Category = &category
+		}
+	}
+
+	if item.LocationID != nil {
+		if i := slices.IndexFunc(s.locations, func(l Location) bool { return l.ID == *item.LocationID }); i >= 0 {
+			location := s.locations[i]
+			item.Location = &location
+		}
+	}
+
+	return item
+}
+
+func (s *MemoryStore) GetGroceryItems() ([]GroceryItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := []GroceryItem{}
+	for _, item := range s.items {
+		if !item.DeletedAt.Valid {
+			items = append(items, s.withRelations(item))
+		}
+	}
+
+	return items, nil
+}
+
+func (s *MemoryStore) GetGroceryItemByName(itemName string) (GroceryItem, error) {
+	name := normalizeName(itemName)
+
+	if len(name) <= 0 {
+		return GroceryItem{}, errors.New("Please type a grocery item.")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.itemIndexByName(name)
+	if i < 0 {
+		return GroceryItem{}, errors.New("There's no grocery item with that name.")
+	}
+
+	return s.withRelations(s.items[i]), nil
+}
+
+func (s *MemoryStore) GetGroceryItemByID(id uint) (GroceryItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.getGroceryItemByID(id)
+}
+
+func (s *MemoryStore) getGroceryItemByID(id uint) (GroceryItem, error) {
+	i := s.itemIndex(id)
+	if i < 0 {
+		return GroceryItem{}, errors.New("There's no grocery item with that id.")
+	}
+
+	return s.withRelations(s.items[i]), nil
+}
+
+func (s *MemoryStore) UpsertGroceryItem(draft GroceryItem) (GroceryItem, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.upsertGroceryItem(draft)
+}
+
+// ImportGroceryItems puts the store back as it was if any draft fails, like
+// the transaction GormStore uses.
+func (s *MemoryStore) ImportGroceryItems(drafts []GroceryItem) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saved, nextID := s.exportSnapshot(), s.nextID
+	added := 0
+
+	for _, draft := range drafts {
+		_, created, err := s.upsertGroceryItem(draft)
+		if err != nil {
+			s.items = saved.Items
+			s.events = saved.Events
+			s.shoppingList = saved.ShoppingList
+			s.nextID = nextID
+			return 0, err
+		}
+		if created {
+			added++
+		}
+	}
+
+	return added, nil
+}
+
+func (s *MemoryStore) upsertGroceryItem(draft GroceryItem) (GroceryItem, bool, error) {
+	name := normalizeName(draft.Name)
+	unit := units.Canonical(draft.Unit)
+	count := draft.Count
+
+	if len(name) <= 0 {
+		return GroceryItem{}, false, errors.New("Please type a grocery item.")
+	}
+
+	i := s.itemIndexByName(name)
+	if i < 0 {
+		item := GroceryItem{
+			Model:      s.newModel(),
+			Name:       name,
+			Count:      count,
+			Unit:       unit,
+			CategoryID: draft.CategoryID,
+			LocationID: draft.LocationID,
+			ExpiresAt:  draft.ExpiresAt,
+			MinCount:   draft.MinCount,
+		}
+		s.items = append(s.items, item)
+		s.recordEvent(item, EventAdd, count, "added to the inventory")
+		s.reconcileShoppingList(item)
+
+		item, err := s.getGroceryItemByID(item.ID)
+		return item, true, err
+	}
+
+	item := s.items[i]
+
+	if draft.CategoryID != nil {
+		item.CategoryID = draft.CategoryID
+	}
+
+	if draft.LocationID != nil {
+		item.LocationID = draft.LocationID
+	}
+
+	if draft.ExpiresAt != nil && (item.ExpiresAt == nil || draft.ExpiresAt.Before(*item.ExpiresAt)) {
+		item.ExpiresAt = draft.ExpiresAt
+	}
+
+	if draft.MinCount > 0 {
+		item.MinCount = draft.MinCount
+	}
+
+	converted, err := units.Convert(count, unit, item.Unit)
+	if err != nil && item.Count != 0 {
+		return GroceryItem{}, false, fmt.Errorf("%s is tracked in %s. %w", item.Name, unitName(item.Unit), err)
+	}
+	if err != nil {
+		item.Unit = unit
+		converted = count
+	}
+
+	note := "restocked"
+	if unit != item.Unit {
+		note = "restocked with " + quantityText(count, unit)
+	}
+
+	item.Count += converted
+	item.UpdatedAt = time.Now()
+	s.items[i] = item
+	s.recordEvent(item, EventAdd, converted, note)
+	s.reconcileShoppingList(item)
+
+	item, err = s.getGroceryItemByID(item.ID)
+	return item, false, err
+}
+
+func (s *MemoryStore) UpdateGroceryItem(changes GroceryItem) (GroceryItem, error) {
+	return s.saveGroceryItem(changes, "")
+}
+
+func (s *MemoryStore) RevertGroceryItem(state GroceryItem, kind EventKind) (GroceryItem, error) {
+	if kind != EventUndo && kind != EventRedo {
+		return GroceryItem{}, fmt.Errorf("%q isn't an undo or redo.", kind)
+	}
+
+	return s.saveGroceryItem(state, kind)
+}
+
+func (s *MemoryStore) saveGroceryItem(changes GroceryItem, kind EventKind) (GroceryItem, error) {
+	name := normalizeName(changes.Name)
+
+	if len(name) <= 0 {
+		return GroceryItem{}, errors.New("Please type a grocery item.")
+	}
+
+	if changes.Count < 0 {
+		return GroceryItem{}, errors.New("Count can't be negative.")
+	}
+
+	if changes.MinCount < 0 {
+		return GroceryItem{}, errors.New("Minimum count can't be negative.")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.itemIndex(changes.ID)
+	if i < 0 {
+		return GroceryItem{}, errors.New("There's no grocery item with that id.")
+	}
+
+	if j := s.itemIndexByName(name); j >= 0 && j != i {
+		return GroceryItem{}, errors.New("There's already a grocery item with that name.")
+	}
+
+	item := &s.items[i]
+	before := *item
+	item.Name = name
+	item.Count = changes.Count
+	item.Unit = units.Canonical(changes.Unit)
+	item.CategoryID = changes.CategoryID
+	item.LocationID = changes.LocationID
+	item.ExpiresAt = changes.ExpiresAt
+	item.MinCount = changes.MinCount
+	item.UpdatedAt = time.Now()
+
+	note := editNote(before, *item)
+	if kind == "" {
+		kind = EventEdit
+		if item.Count != before.Count {
+			kind = EventAdjust
+		}
+	} else {
+		note = revertNote(kind, note)
+	}
+	s.recordEvent(*item, kind, item.Count-before.Count, note)
+	s.reconcileShoppingList(*item)
+
+	return s.getGroceryItemByID(item.ID)
+}
+
+func (s *MemoryStore) AdjustGroceryItemCount(id uint, delta float64) (GroceryItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.itemIndex(id)
+	if i < 0 {
+		return GroceryItem{}, errors.New("There's no grocery item with that id.")
+	}
+
+	item := &s.items[i]
+	change := max(item.Count+delta, 0) - item.Count
+	if change == 0 {
+		return s.getGroceryItemByID(id)
+	}
+
+	item.Count += change
+	item.UpdatedAt = time.Now()
+
+	kind, note := EventAdd, "counted up"
+	if change < 0 {
+		kind, note = EventConsume, "used"
+	}
+	s.recordEvent(*item, kind, change, note)
+	s.reconcileShoppingList(*item)
+
+	return s.getGroceryItemByID(id)
+}
+
+func (s *MemoryStore) DeleteGroceryItemByID(id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.itemIndex(id)
+	if i < 0 {
+		return errors.New("There's no grocery item with that id.")
+	}
+
+	s.items[i].DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	s.recordEvent(s.items[i], EventDelete, -s.items[i].Count, "deleted")
+	s.reconcileShoppingList(s.items[i])
+
+	return nil
+}
+
+func (s *MemoryStore) DiscardGroceryItem(id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.itemIndex(id) < 0 {
+		return errors.New("There's no grocery item with that id.")
+	}
+
+	s.purge(func(item GroceryItem) bool { return item.ID == id })
+
+	return nil
+}
+
+func (s *MemoryStore) RestoreGroceryItem(id uint) (GroceryItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.items, func(item GroceryItem) bool {
+		return item.ID == id && item.DeletedAt.Valid
+	})
+	if i < 0 {
+		return GroceryItem{}, errors.New("There's no deleted grocery item with that id.")
+	}
+
+	if s.itemIndexByName(s.items[i].Name) >= 0 {
+		return GroceryItem{}, fmt.Errorf("There's already a grocery item called %s.", s.items[i].Name)
+	}
+
+	item := &s.items[i]
+	item.DeletedAt = gorm.DeletedAt{}
+	s.recordEvent(*item, EventRestore, item.Count, "restored")
+	s.reconcileShoppingList(*item)
+
+	return s.getGroceryItemByID(id)
+}
+
+func (s *MemoryStore) GetDeletedGroceryItems() ([]GroceryItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := []GroceryItem{}
+	for _, item := range s.items {
+		if item.DeletedAt.Valid {
+			items = append(items, s.withRelations(item))
+		}
+	}
+
+	slices.SortStableFunc(items, func(a, b GroceryItem) int {
+		return b.DeletedAt.Time.Compare(a.DeletedAt.Time)
+	})
+
+	return items, nil
+}
+
+func (s *MemoryStore) PurgeGroceryItem(id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !slices.ContainsFunc(s.items, func(item GroceryItem) bool { return item.ID == id && item.DeletedAt.Valid }) {
+		return errors.New("There's no deleted grocery item with that id.")
+	}
+
+	s.purge(func(item GroceryItem) bool { return item.ID == id && item.DeletedAt.Valid })
+
+	return nil
+}
+
+func (s *MemoryStore) PurgeDeletedGroceryItems(cutoff time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.purge(func(item GroceryItem) bool {
+		return item.DeletedAt.Valid && item.DeletedAt.Time.Before(cutoff)
+	}), nil
+}
+
+// purge drops the items matching doomed, with their history and grocery
+// list entries, and reports how many went.
+func (s *MemoryStore) purge(doomed func(GroceryItem) bool) int {
+	gone := map[uint]bool{}
+	s.items = slices.DeleteFunc(s.items, func(item GroceryItem) bool {
+		if doomed(item) {
+			gone[item.ID] = true
+		}
+		return gone[item.ID]
+	})
+
+	s.events = slices.DeleteFunc(s.events, func(event InventoryEvent) bool {
+		return gone[event.GroceryItemID]
+	})
+	s.shoppingList = slices.DeleteFunc(s.shoppingList, func(entry ShoppingListItem) bool {
+		return entry.GroceryItemID != nil && gone[*entry.GroceryItemID]
+	})
+
+	return len(gone)
+}
+
+func (s *MemoryStore) WipeGroceryItems() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for i := range s.items {
+		if !s.items[i].DeletedAt.Valid {
+			s.items[i].DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+			s.recordEvent(s.items[i], EventDelete, -s.items[i].Count, "inventory wiped")
+			s.reconcileShoppingList(s.items[i])
+		}
+	}
+
+	return nil
+}
+
+// reconcileShoppingList is the in-memory twin of the GormStore version.
+func (s *MemoryStore) reconcileShoppingList(item GroceryItem) {
+	i := slices.IndexFunc(s.shoppingList, func(entry ShoppingListItem) bool {
+		return entry.GroceryItemID != nil && *entry.GroceryItemID == item.ID
+	})
+
+	shortfall := item.MinCount - item.Count
+	if item.DeletedAt.Valid {
+		shortfall = 0
+	}
+
+	switch {
+	case shortfall <= 0 && i >= 0:
+		s.shoppingList = slices.Delete(s.shoppingList, i, i+1)
+	case shortfall > 0 && i < 0:
+		id := item.ID
+		s.shoppingList = append(s.shoppingList, ShoppingListItem{
+			Model:         s.newModel(),
+			Name:          item.Name,
+			Quantity:      shortfall,
+			Unit:          item.Unit,
+			GroceryItemID: &id,
+		})
+	case shortfall > 0:
+		entry := &s.shoppingList[i]
+		entry.Name = item.Name
+		entry.Quantity = shortfall
+		entry.Unit = item.Unit
+		entry.UpdatedAt = time.Now()
+	}
+}
+
+func (s *MemoryStore) GetShoppingListItems() ([]ShoppingListItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.shoppingList), nil
+}
+
+func (s *MemoryStore) CreateShoppingListItem(itemName string) (ShoppingListItem, error) {
+	name := normalizeName(itemName)
+
+	if len(name) <= 0 {
+		return ShoppingListItem{}, errors.New("Please type a grocery item.")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item := ShoppingListItem{Model: s.newModel(), Name: name}
+	s.shoppingList = append(s.shoppingList, item)
+
+	return item, nil
+}
+
+func (s *MemoryStore) SetShoppingListItemChecked(id uint, checked bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.shoppingList, func(item ShoppingListItem) bool { return item.ID == id })
+	if i < 0 {
+		return errors.New("There's no list item with that id.")
+	}
+
+	s.shoppingList[i].Checked = checked
+	s.shoppingList[i].UpdatedAt = time.Now()
+
+	return nil
+}
+
+func (s *MemoryStore) DeleteShoppingListItem(id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.shoppingList, func(item ShoppingListItem) bool { return item.ID == id })
+	if i < 0 {
+		return errors.New("There's no list item with that id.")
+	}
+
+	s.shoppingList = slices.Delete(s.shoppingList, i, i+1)
+
+	return nil
+}
+
+func (s *MemoryStore) WipeShoppingList() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.shoppingList = nil
+
+	return nil
+}
+
+func (s *MemoryStore) GetCategories() ([]Category, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.categories), nil
+}
+
+func (s *MemoryStore) CreateCategory(categoryName string) (Category, error) {
+	name := normalizeName(categoryName)
+
+	if len(name) <= 0 {
+		return Category{}, errors.New("Please type a category.")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if slices.ContainsFunc(s.categories, func(c Category) bool { return c.Name == name }) {
+		return Category{}, errors.New("There's already a category with that name.")
+	}
+
+	category := Category{Model: s.newModel(), Name: name}
+	s.categories = append(s.categories, category)
+
+	return category, nil
+}
+
+func (s *MemoryStore) GetLocations() ([]Location, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	locations := slices.Clone(s.locations)
+	slices.SortStableFunc(locations, func(a, b Location) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	return locations, nil
+}
+
+func (s *MemoryStore) CreateLocation(locationName string, parentID *uint) (Location, error) {
+	name := strings.Join(strings.Fields(locationName), " ")
+
+	if len(name) <= 0 {
+		return Location{}, errors.New("Please type a location.")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if slices.ContainsFunc(s.locations, func(l Location) bool { return strings.EqualFold(l.Name, name) && sameID(l.ParentID, parentID) }) {
+		return Location{}, errors.New("There's already a location with that name here.")
+	}
+
+	location := Location{Model: s.newModel(), Name: name, ParentID: parentID}
+	s.locations = append(s.locations, location)
+
+	return location, nil
+}
+
+func (s *MemoryStore) DeleteLocation(id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.locations, func(l Location) bool { return l.ID == id })
+	if i < 0 {
+		return errors.New("There's no location with that id.")
+	}
+
+	if slices.ContainsFunc(s.locations, func(l Location) bool { return l.ParentID != nil && *l.ParentID == id }) {
+		return errors.New("Move or delete the locations inside this one first.")
+	}
+
+	parentID := s.locations[i].ParentID
+	note := locationDeletedNote(s.locations[i])
+	for j := range s.items {
+		if s.items[j].LocationID != nil && *s.items[j].LocationID == id {
+			s.items[j].LocationID = parentID
+			s.items[j].UpdatedAt = time.Now()
+			s.recordEvent(s.items[j], EventEdit, 0, note)
+		}
+	}
+
+	s.locations = slices.Delete(s.locations, i, i+1)
+
+	return nil
+}
+
+func (s *MemoryStore) GetSettings() (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	values := map[string]string{}
+	for key, value := range s.settings {
+		values[key] = value
+	}
+
+	return values, nil
+}
+
+func (s *MemoryStore) SetSetting(key string, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.settings[key] = value
+
+	return nil
+}
+
+func (s *MemoryStore) ExportSnapshot() (Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.exportSnapshot(), nil
+}
+
+func (s *MemoryStore) exportSnapshot() Snapshot {
+	snapshot := Snapshot{
+		Version:      SnapshotVersion,
+		ExportedAt:   time.Now(),
+		Categories:   slices.Clone(s.categories),
+		Locations:    slices.Clone(s.locations),
+		Items:        slices.Clone(s.items),
+		Events:       slices.Clone(s.events),
+		ShoppingList: slices.Clone(s.shoppingList),
+		Settings:     map[string]string{},
+	}
+	for key, value := range s.settings {
+		snapshot.Settings[key] = value
+	}
+
+	return snapshot
+}
+
+func (s *MemoryStore) ImportSnapshot(snapshot Snapshot, replace bool) (int, error) {
+	if err := snapshot.Validate(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	imported := liveItemCount(snapshot.Items)
+	if !replace {
+		snapshot, imported = mergeSnapshots(s.exportSnapshot(), snapshot)
+	}
+
+	s.categories = snapshot.Categories
+	s.locations = snapshot.Locations
+	s.items = snapshot.Items
+	s.events = snapshot.Events
+	s.shoppingList = snapshot.ShoppingList
+	s.settings = snapshot.Settings
+
+	// Carry on numbering after the highest ID the snapshot brought in.
+	s.nextID = max(
+		uint(allocatorAbove(s.categories, func(c Category) uint { return c.ID })),
+		uint(allocatorAbove(s.locations, func(l Location) uint { return l.ID })),
+		uint(allocatorAbove(s.items, func(item GroceryItem) uint { return item.ID })),
+		uint(allocatorAbove(s.events, func(e InventoryEvent) uint { return e.ID })),
+		uint(allocatorAbove(s.shoppingList, func(e ShoppingListItem) uint { return e.ID })),
+	)
+
+	return imported, nil
+}
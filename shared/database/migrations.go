@@ -0,0 +1,376 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration is one numbered step in the schema's history. Versions are
+// applied in order and recorded in the schema_migrations table.
+type Migration struct {
+	Version int
+	Name    string
+	// Loses says what reverting the migration throws away, so it can be
+	// confirmed first.
+	Loses string
+	up    func(tx *gorm.DB) error
+	down  func(tx *gorm.DB) error
+}
+
+// schemaMigration is a row of the schema_migrations table.
+type schemaMigration struct {
+	Version   int `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationStatus pairs a migration with when it was applied, if it has been.
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// migrations is the schema's history. Append new steps here; never edit or
+// renumber one that has shipped.
+var migrations = []Migration{
+	{Version: 1, Name: "baseline schema", up: baselineUp},
+	{
+		Version: 2,
+		Name:    "inventory events",
+		Loses:   "every item's history",
+		up: func(tx *gorm.DB) error {
+			return execAll(tx,
+				"CREATE TABLE `inventory_events` (`id` integer PRIMARY KEY AUTOINCREMENT,`grocery_item_id` integer,`kind` text,`delta` real,`unit` text,`note` text,`created_at` datetime)",
+				"CREATE INDEX `idx_inventory_events_grocery_item_id` ON `inventory_events`(`grocery_item_id`)",
+			)
+		},
+		down: func(tx *gorm.DB) error {
+			return execAll(tx, "DROP TABLE `inventory_events`")
+		},
+	},
+}
+
+// LatestSchemaVersion is the newest schema this build understands.
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+func appliedMigrations(db *gorm.DB) ([]schemaMigration, error) {
+	if err := db.AutoMigrate(&schemaMigration{}); err != nil {
+		return nil, err
+	}
+
+	var applied []schemaMigration
+	result := db.Order("version").Find(&applied)
+
+	return applied, result.Error
+}
+
+// checkSchemaVersion refuses databases written by a newer chef, whose schema
+// this build could only damage.
+func checkSchemaVersion(applied []schemaMigration) error {
+	if len(applied) == 0 {
+		return nil
+	}
+
+	if version := applied[len(applied)-1].Version; version > LatestSchemaVersion() {
+		return fmt.Errorf("This database is at schema version %d but this chef only knows up to version %d. Please upgrade chef.", version, LatestSchemaVersion())
+	}
+
+	return nil
+}
+
+// Migrator runs migrations by hand, for the migrate command. Unlike
+// OpenGormStore it leaves the schema as it finds it when opened.
+type Migrator struct {
+	db *gorm.DB
+}
+
+func OpenMigrator(path string) (*Migrator, error) {
+	db, err := openSQLite(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db}, nil
+}
+
+// Status lists every known migration and whether it's applied.
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	db := m.db
+
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []MigrationStatus{}
+	for _, migration := range migrations {
+		status := MigrationStatus{Migration: migration}
+		if i := slices.IndexFunc(applied, func(a schemaMigration) bool { return a.Version == migration.Version }); i >= 0 {
+			status.AppliedAt = &applied[i].AppliedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, checkSchemaVersion(applied)
+}
+
+// Up applies every pending migration in order, each in its own transaction,
+// and returns the ones it applied.
+func (m *Migrator) Up() ([]Migration, error) {
+	return migrateUp(m.db)
+}
+
+func migrateUp(db *gorm.DB) ([]Migration, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkSchemaVersion(applied); err != nil {
+		return nil, err
+	}
+
+	done := []Migration{}
+
+	for _, migration := range migrations {
+		if slices.ContainsFunc(applied, func(a schemaMigration) bool { return a.Version == migration.Version }) {
+			continue
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&schemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %d (%s) failed: %w", migration.Version, migration.Name, err)
+		}
+
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// LastApplied returns the migration Down would revert, or an error saying
+// why there isn't one.
+func (m *Migrator) LastApplied() (Migration, error) {
+	db := m.db
+
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return Migration{}, err
+	}
+
+	if err := checkSchemaVersion(applied); err != nil {
+		return Migration{}, err
+	}
+
+	if len(applied) == 0 {
+		return Migration{}, errors.New("There are no migrations to revert.")
+	}
+
+	last := applied[len(applied)-1]
+	i := slices.IndexFunc(migrations, func(m Migration) bool { return m.Version == last.Version })
+	migration := migrations[i]
+
+	if migration.down == nil {
+		return migration, fmt.Errorf("Migration %d (%s) can't be reverted.", migration.Version, migration.Name)
+	}
+
+	return migration, nil
+}
+
+// Down reverts the most recently applied migration. Opening the database
+// with OpenGormStore applies it again.
+func (m *Migrator) Down() (Migration, error) {
+	db := m.db
+
+	migration, err := m.LastApplied()
+	if err != nil {
+		return migration, err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := migration.down(tx); err != nil {
+			return err
+		}
+		return tx.Delete(&schemaMigration{}, migration.Version).Error
+	})
+	if err != nil {
+		return migration, fmt.Errorf("reverting migration %d (%s) failed: %w", migration.Version, migration.Name, err)
+	}
+
+	return migration, nil
+}
+
+// execAll runs statements in order, stopping at the first error.
+func execAll(tx *gorm.DB, statements ...string) error {
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addColumns adds the columns a table is missing. Each column is given as
+// "name definition".
+func addColumns(tx *gorm.DB, table string, columns ...string) error {
+	for _, column := range columns {
+		name, _, _ := strings.Cut(column, " ")
+		if tx.Migrator().HasColumn(table, name) {
+			continue
+		}
+		if err := tx.Exec(fmt.Sprintf("ALTER TABLE `%s` ADD COLUMN `%s`", table, name) + strings.TrimPrefix(column, name)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const groceryItemsTable = "CREATE TABLE IF NOT EXISTS `grocery_items` (" +
+	"`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime," +
+	"`name` text,`count` real,`unit` text,`category_id` integer,`location_id` integer,`expires_at` datetime,`min_count` real," +
+	"CONSTRAINT `fk_grocery_items_location` FOREIGN KEY (`location_id`) REFERENCES `locations`(`id`)," +
+	"CONSTRAINT `fk_grocery_items_category` FOREIGN KEY (`category_id`) REFERENCES `categories`(`id`))"
+
+// baselineUp creates the schema as it stood when migrations were introduced.
+// Databases from before then were kept up to date by AutoMigrate and can be
+// at any point along the way, so every step checks what's already there.
+func baselineUp(tx *gorm.DB) error {
+	err := execAll(tx,
+		"CREATE TABLE IF NOT EXISTS `categories` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`name` text)",
+		"CREATE UNIQUE INDEX IF NOT EXISTS `idx_categories_name` ON `categories`(`name`)",
+		"CREATE INDEX IF NOT EXISTS `idx_categories_deleted_at` ON `categories`(`deleted_at`)",
+
+		"CREATE TABLE IF NOT EXISTS `locations` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`name` text,`parent_id` integer,"+
+			"CONSTRAINT `fk_locations_children` FOREIGN KEY (`parent_id`) REFERENCES `locations`(`id`))",
+		"CREATE INDEX IF NOT EXISTS `idx_locations_parent_id` ON `locations`(`parent_id`)",
+		"CREATE INDEX IF NOT EXISTS `idx_locations_deleted_at` ON `locations`(`deleted_at`)",
+
+		groceryItemsTable,
+
+		"CREATE TABLE IF NOT EXISTS `shopping_list_items` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`name` text,`checked` numeric)",
+
+		"CREATE TABLE IF NOT EXISTS `settings` (`key` text,`value` text,`updated_at` datetime,PRIMARY KEY (`key`))",
+	)
+	if err != nil {
+		return err
+	}
+
+	err = addColumns(tx, "grocery_items",
+		"unit text",
+		"category_id integer",
+		"location_id integer",
+		"expires_at datetime",
+		"min_count real",
+	)
+	if err != nil {
+		return err
+	}
+
+	err = addColumns(tx, "shopping_list_items",
+		"quantity real",
+		"unit text",
+		"grocery_item_id integer",
+	)
+	if err != nil {
+		return err
+	}
+
+	// The very first databases stored counts as integers, which can't hold
+	// "1.5 kg". SQLite can't change a column's type, so copy the table.
+	columns, err := tx.Migrator().ColumnTypes("grocery_items")
+	if err != nil {
+		return err
+	}
+	for _, column := range columns {
+		if column.Name() == "count" && !strings.EqualFold(column.DatabaseTypeName(), "real") {
+			if err := rebuildGroceryItems(tx); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := mergeDuplicateGroceryItems(tx); err != nil {
+		return err
+	}
+
+	err = execAll(tx,
+		"CREATE INDEX IF NOT EXISTS `idx_grocery_items_deleted_at` ON `grocery_items`(`deleted_at`)",
+		"CREATE UNIQUE INDEX IF NOT EXISTS `idx_grocery_items_name` ON `grocery_items`(`name`) WHERE deleted_at IS NULL",
+		"CREATE INDEX IF NOT EXISTS `idx_shopping_list_items_grocery_item_id` ON `shopping_list_items`(`grocery_item_id`)",
+		"CREATE INDEX IF NOT EXISTS `idx_shopping_list_items_deleted_at` ON `shopping_list_items`(`deleted_at`)",
+	)
+	if err != nil {
+		return err
+	}
+
+	return seedCategories(tx)
+}
+
+func rebuildGroceryItems(tx *gorm.DB) error {
+	const columns = "`id`,`created_at`,`updated_at`,`deleted_at`,`name`,`count`,`unit`,`category_id`,`location_id`,`expires_at`,`min_count`"
+
+	return execAll(tx,
+		"ALTER TABLE `grocery_items` RENAME TO `grocery_items_old`",
+		"DROP INDEX IF EXISTS `idx_grocery_items_deleted_at`",
+		"DROP INDEX IF EXISTS `idx_grocery_items_name`",
+		groceryItemsTable,
+		"INSERT INTO `grocery_items` ("+columns+") SELECT "+columns+" FROM `grocery_items_old`",
+		"DROP TABLE `grocery_items_old`",
+	)
+}
+
+// mergeDuplicateGroceryItems folds items that share a normalized name into the
+// oldest of them. Databases created before names were unique can hold
+// duplicates, which would stop the unique index from being created.
+func mergeDuplicateGroceryItems(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var items []GroceryItem
+		if err := tx.Order("id").Find(&items).Error; err != nil {
+			return err
+		}
+
+		kept := map[string]*GroceryItem{}
+
+		for i := range items {
+			item := &items[i]
+			name := normalizeName(item.Name)
+
+			original, ok := kept[name]
+			if !ok {
+				kept[name] = item
+				if name != item.Name {
+					if err := tx.Model(item).Update("name", name).Error; err != nil {
+						return err
+					}
+				}
+				continue
+			}
+
+			original.Count += item.Count
+			if err := tx.Model(original).Update("count", original.Count).Error; err != nil {
+				return err
+			}
+			if err := tx.Delete(item).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
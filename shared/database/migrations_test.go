@@ -0,0 +1,184 @@
+package database
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// legacyDatabase writes a database the way the very first chef left it:
+// integer counts and names that were never made unique.
+func legacyDatabase(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "chef.db")
+	db, err := openSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = execAll(db,
+		"CREATE TABLE `grocery_items` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`name` text,`count` integer)",
+		"INSERT INTO `grocery_items` (`name`,`count`) VALUES ('Milk ', 2), ('eggs', 12), ('milk', 3), ('EGGS', 6), ('bread', 1)",
+		"CREATE TABLE `shopping_list_items` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`name` text,`checked` numeric)",
+		"INSERT INTO `shopping_list_items` (`name`,`checked`) VALUES ('bananas', 0)",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.Close()
+
+	return path
+}
+
+func TestMigrateLegacyDatabase(t *testing.T) {
+	store, err := OpenGormStore(legacyDatabase(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items, err := store.GetGroceryItems()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := map[string]float64{}
+	for _, item := range items {
+		counts[item.Name] = item.Count
+	}
+	want := map[string]float64{"milk": 5, "eggs": 18, "bread": 1}
+	if len(counts) != len(want) {
+		t.Fatalf("items = %v, want %v", counts, want)
+	}
+	for name, count := range want {
+		if counts[name] != count {
+			t.Errorf("%s = %v, want %v", name, counts[name], count)
+		}
+	}
+
+	milk, err := store.GetGroceryItemByName("milk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if milk.ID != 1 {
+		t.Errorf("milk was merged into item %d, want the oldest, 1", milk.ID)
+	}
+
+	// Counts are real numbers now.
+	milk.Count = 1.5
+	if milk, err = store.UpdateGroceryItem(milk); err != nil || milk.Count != 1.5 {
+		t.Errorf("saving 1.5 = %v, %v", milk.Count, err)
+	}
+
+	// And names are unique.
+	if !store.db.Migrator().HasIndex("grocery_items", "idx_grocery_items_name") {
+		t.Error("the unique name index wasn't created")
+	}
+
+	entries, err := store.GetShoppingListItems()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name != "bananas" {
+		t.Errorf("grocery list = %+v, want bananas kept", entries)
+	}
+
+	categories, err := store.GetCategories()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(categories) != len(defaultCategories) {
+		t.Errorf("%d categories, want the %d defaults", len(categories), len(defaultCategories))
+	}
+}
+
+func TestMigrateDownAndUp(t *testing.T) {
+	migrator, err := OpenMigrator(filepath.Join(t.TempDir(), "chef.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := migrator.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != len(migrations) {
+		t.Fatalf("Up() applied %d migrations, want %d", len(applied), len(migrations))
+	}
+
+	if applied, err := migrator.Up(); err != nil || len(applied) != 0 {
+		t.Errorf("second Up() = %d, %v, want nothing to do", len(applied), err)
+	}
+
+	statuses, err := migrator.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			t.Errorf("migration %d isn't applied", status.Version)
+		}
+	}
+
+	last, err := migrator.LastApplied()
+	if err != nil || last.Version != LatestSchemaVersion() || last.Loses == "" {
+		t.Errorf("LastApplied() = %+v, %v, want the latest migration and what reverting it loses", last, err)
+	}
+
+	reverted, err := migrator.Down()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reverted.Version != LatestSchemaVersion() {
+		t.Errorf("Down() reverted %d, want %d", reverted.Version, LatestSchemaVersion())
+	}
+	if migrator.db.Migrator().HasTable(&InventoryEvent{}) {
+		t.Error("inventory_events is still there after reverting it")
+	}
+
+	statuses, err = migrator.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statuses[len(statuses)-1].AppliedAt != nil {
+		t.Error("the reverted migration is still marked applied")
+	}
+
+	if _, err := migrator.Down(); err == nil || !strings.Contains(err.Error(), "can't be reverted") {
+		t.Errorf("reverting the baseline = %v, want it refused", err)
+	}
+
+	if applied, err := migrator.Up(); err != nil || len(applied) != 1 {
+		t.Errorf("Up() after Down() = %d, %v, want the reverted migration again", len(applied), err)
+	}
+	if !migrator.db.Migrator().HasTable(&InventoryEvent{}) {
+		t.Error("inventory_events wasn't recreated")
+	}
+}
+
+func TestMigrateRefusesNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chef.db")
+
+	migrator, err := OpenMigrator(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.db.Create(&schemaMigration{Version: LatestSchemaVersion() + 1, Name: "from the future"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := OpenGormStore(path); err == nil {
+		t.Error("OpenGormStore() opened a database from a newer chef")
+	}
+	if _, err := migrator.Down(); err == nil {
+		t.Error("Down() reverted a migration this chef doesn't know")
+	}
+}
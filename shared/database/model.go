@@ -2,74 +2,593 @@ package database
 
 import (
 	"errors"
+	"fmt"
 	"strings"
+	"time"
 
-	"github.com/charmbracelet/log"
+	"github.com/lundjrl/go-bubble-tea-playground/shared/units"
 	"gorm.io/gorm"
 )
 
+// Model is gorm.Model with json tags, so records read the same in exports
+// as the rest of their fields.
+type Model struct {
+	ID        uint           `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deletedAt"`
+}
+
 type GroceryItem struct {
-	gorm.Model
-	Name  string `json:"name"`
-	Count int    `json:"count"`
+	Model
+	Name  string  `gorm:"uniqueIndex:idx_grocery_items_name,where:deleted_at IS NULL" json:"name"`
+	Count float64 `json:"count"`
+	// Unit is a canonical unit name from the units package, or empty for a
+	// plain count.
+	Unit       string     `json:"unit"`
+	CategoryID *uint      `json:"categoryId"`
+	Category   *Category  `json:"category,omitempty"`
+	LocationID *uint      `json:"locationId"`
+	Location   *Location  `json:"location,omitempty"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	// MinCount is the par level, in Unit. Dropping below it puts the
+	// shortfall on the grocery list.
+	MinCount float64 `json:"minCount"`
+}
+
+// unitName describes a unit in messages, where an empty unit reads as "each".
+func unitName(unit string) string {
+	if unit == "" {
+		return "each"
+	}
+	return unit
 }
 
-// func GetGroceryItems() (*sql.rows, error){
-// 	db := DBConn
-// 	var items []GroceryItem
-// 	result := db.Find(&items)
-// 	return result.Rows()
-// }
+// normalizeName lowercases a name and collapses its whitespace so that
+// "Milk " and "milk" are stored as the same item.
+func normalizeName(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+func (s *GormStore) GetGroceryItems() ([]GroceryItem, error) {
+	db := s.db
+
+	var items []GroceryItem
+	result := db.Preload("Category").Preload("Location").Order("id").Find(&items)
+
+	return items, result.Error
+}
 
-func GetGroceryItemByName(itemName string) (string, error) {
-	name := strings.ToLower(itemName)
+func (s *GormStore) GetGroceryItemByName(itemName string) (GroceryItem, error) {
+	name := normalizeName(itemName)
 
 	if len(name) <= 0 {
-		return "", errors.New("Please type a grocery item.")
+		return GroceryItem{}, errors.New("Please type a grocery item.")
 	}
 
-	db := DBConn
+	db := s.db
 
 	var item GroceryItem
-	result := db.Find(&item, "Name = ?", name)
-	return result.Name(), result.Error
+	result := db.Preload("Category").Preload("Location").Where("name = ?", name).Limit(1).Find(&item)
+
+	if result.Error == nil && result.RowsAffected == 0 {
+		return item, errors.New("There's no grocery item with that name.")
+	}
+
+	return item, result.Error
+}
+
+func (s *GormStore) GetGroceryItemByID(id uint) (GroceryItem, error) {
+	db := s.db
+
+	var item GroceryItem
+	result := db.Preload("Category").Preload("Location").First(&item, id)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return item, errors.New("There's no grocery item with that id.")
+	}
+
+	return item, result.Error
 }
 
-func CreateGroceryItem(itemName string) (string, error) {
-	name := strings.ToLower(itemName)
+// UpsertGroceryItem creates draft, or adds its count to the existing item with
+// the same normalized name. created reports which of the two happened.
+func (s *GormStore) UpsertGroceryItem(draft GroceryItem) (item GroceryItem, created bool, err error) {
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		item, created, err = upsertGroceryItem(tx, draft)
+		return err
+	})
+
+	if err != nil {
+		return item, created, err
+	}
+
+	item, err = s.GetGroceryItemByID(item.ID)
+	return item, created, err
+}
+
+// ImportGroceryItems upserts every draft in one transaction, so if one fails
+// none of them are saved. It reports how many were new items.
+func (s *GormStore) ImportGroceryItems(drafts []GroceryItem) (int, error) {
+	added := 0
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, draft := range drafts {
+			_, created, err := upsertGroceryItem(tx, draft)
+			if err != nil {
+				return err
+			}
+			if created {
+				added++
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return added, nil
+}
+
+// upsertGroceryItem does the work of UpsertGroceryItem inside tx.
+func upsertGroceryItem(tx *gorm.DB, draft GroceryItem) (item GroceryItem, created bool, err error) {
+	name := normalizeName(draft.Name)
+	unit := units.Canonical(draft.Unit)
+	count := draft.Count
 
 	if len(name) <= 0 {
-		return "", errors.New("Please type a grocery item.")
+		return item, false, errors.New("Please type a grocery item.")
 	}
 
-	db := DBConn
-	item := new(GroceryItem)
-	item.Name = name
-	item.Count = 1
+	result := tx.Where("name = ?", name).Limit(1).Find(&item)
+	if result.Error != nil {
+		return item, false, result.Error
+	}
 
-	result := db.Create(&item)
-	// log.Info("Created ::", item)
+	if result.RowsAffected == 0 {
+		item = GroceryItem{
+			Name:       name,
+			Count:      count,
+			Unit:       unit,
+			CategoryID: draft.CategoryID,
+			LocationID: draft.LocationID,
+			ExpiresAt:  draft.ExpiresAt,
+			MinCount:   draft.MinCount,
+		}
+		created = true
+		if err := tx.Create(&item).Error; err != nil {
+			return item, created, err
+		}
+		if err := recordEvent(tx, item, EventAdd, count, "added to the inventory"); err != nil {
+			return item, created, err
+		}
+		return item, created, reconcileShoppingList(tx, item.ID)
+	}
+
+	if draft.CategoryID != nil {
+		item.CategoryID = draft.CategoryID
+	}
+
+	if draft.LocationID != nil {
+		item.LocationID = draft.LocationID
+	}
+
+	// Keep the soonest date, that's the stock that needs using first.
+	if draft.ExpiresAt != nil && (item.ExpiresAt == nil || draft.ExpiresAt.Before(*item.ExpiresAt)) {
+		item.ExpiresAt = draft.ExpiresAt
+	}
+
+	if draft.MinCount > 0 {
+		item.MinCount = draft.MinCount
+	}
+
+	// Restocks are converted into the unit the item is already tracked in.
+	// An empty item can switch to whatever unit it's restocked in.
+	converted, err := units.Convert(count, unit, item.Unit)
+	if err != nil && item.Count != 0 {
+		return item, created, fmt.Errorf("%s is tracked in %s. %w", item.Name, unitName(item.Unit), err)
+	}
+	if err != nil {
+		item.Unit = unit
+		converted = count
+	}
+
+	note := "restocked"
+	if unit != item.Unit {
+		note = "restocked with " + quantityText(count, unit)
+	}
+
+	err = tx.Model(&item).Updates(map[string]any{
+		"count":       item.Count + converted,
+		"unit":        item.Unit,
+		"category_id": item.CategoryID,
+		"location_id": item.LocationID,
+		"expires_at":  item.ExpiresAt,
+		"min_count":   item.MinCount,
+	}).Error
+	if err != nil {
+		return item, created, err
+	}
 
-	return "", result.Error
+	if err := recordEvent(tx, item, EventAdd, converted, note); err != nil {
+		return item, created, err
+	}
+
+	return item, created, reconcileShoppingList(tx, item.ID)
+}
+
+// UpdateGroceryItem saves the editable fields of changes onto the item with
+// the same ID.
+func (s *GormStore) UpdateGroceryItem(changes GroceryItem) (GroceryItem, error) {
+	return s.saveGroceryItem(changes, "")
+}
+
+// RevertGroceryItem puts an item back the way state has it, for undo and
+// redo. The history records kind, EventUndo or EventRedo, rather than an edit.
+func (s *GormStore) RevertGroceryItem(state GroceryItem, kind EventKind) (GroceryItem, error) {
+	if kind != EventUndo && kind != EventRedo {
+		return GroceryItem{}, fmt.Errorf("%q isn't an undo or redo.", kind)
+	}
+
+	return s.saveGroceryItem(state, kind)
 }
 
-func DeleteGroceryItem(itemName string) (string, error) {
-	name := strings.ToLower(itemName)
-	db := DBConn
+// saveGroceryItem writes the editable fields of changes. An empty kind
+// records the change as an edit, or an adjustment when the count moved.
+func (s *GormStore) saveGroceryItem(changes GroceryItem, kind EventKind) (GroceryItem, error) {
+	id := changes.ID
+	name := normalizeName(changes.Name)
 
 	if len(name) <= 0 {
-		return "", errors.New("Please type a grocery item.")
+		return GroceryItem{}, errors.New("Please type a grocery item.")
+	}
+
+	if changes.Count < 0 {
+		return GroceryItem{}, errors.New("Count can't be negative.")
 	}
 
+	if changes.MinCount < 0 {
+		return GroceryItem{}, errors.New("Minimum count can't be negative.")
+	}
+
+	db := s.db
+
 	var item GroceryItem
-	db.First(&item, "Name = ?", name)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&item, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("There's no grocery item with that id.")
+			}
+			return err
+		}
+
+		var clashes int64
+		if err := tx.Model(&GroceryItem{}).Where("name = ? AND id <> ?", name, id).Count(&clashes).Error; err != nil {
+			return err
+		}
+
+		if clashes > 0 {
+			return errors.New("There's already a grocery item with that name.")
+		}
 
-	if item.Name == "" {
-		return "", errors.New("There's no grocery item with that name.")
+		before := item
+		err := tx.Model(&item).Updates(map[string]any{
+			"name":        name,
+			"count":       changes.Count,
+			"unit":        units.Canonical(changes.Unit),
+			"category_id": changes.CategoryID,
+			"location_id": changes.LocationID,
+			"expires_at":  changes.ExpiresAt,
+			"min_count":   changes.MinCount,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		note := editNote(before, item)
+		if kind == "" {
+			kind = EventEdit
+			if item.Count != before.Count {
+				kind = EventAdjust
+			}
+		} else {
+			note = revertNote(kind, note)
+		}
+		if err := recordEvent(tx, item, kind, item.Count-before.Count, note); err != nil {
+			return err
+		}
+
+		return reconcileShoppingList(tx, id)
+	})
+
+	if err != nil {
+		return item, err
 	}
 
-	result := db.Delete(&item)
-	log.Info("Item removed.")
+	return s.GetGroceryItemByID(id)
+}
+
+// AdjustGroceryItemCount adds delta to an item's count, stopping at zero.
+func (s *GormStore) AdjustGroceryItemCount(id uint, delta float64) (GroceryItem, error) {
+	db := s.db
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var item GroceryItem
+		if err := tx.First(&item, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("There's no grocery item with that id.")
+			}
+			return err
+		}
+
+		// Counts stop at zero, so record what actually changed.
+		change := max(item.Count+delta, 0) - item.Count
+		if change == 0 {
+			return nil
+		}
+
+		if err := tx.Model(&item).Update("count", item.Count+change).Error; err != nil {
+			return err
+		}
+
+		kind, note := EventAdd, "counted up"
+		if change < 0 {
+			kind, note = EventConsume, "used"
+		}
+		if err := recordEvent(tx, item, kind, change, note); err != nil {
+			return err
+		}
+
+		return reconcileShoppingList(tx, id)
+	})
+
+	if err != nil {
+		return GroceryItem{}, err
+	}
+
+	return s.GetGroceryItemByID(id)
+}
+
+func (s *GormStore) DeleteGroceryItemByID(id uint) error {
+	db := s.db
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var item GroceryItem
+		if err := tx.First(&item, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("There's no grocery item with that id.")
+			}
+			return err
+		}
+
+		if err := tx.Delete(&item).Error; err != nil {
+			return err
+		}
+
+		if err := recordEvent(tx, item, EventDelete, -item.Count, "deleted"); err != nil {
+			return err
+		}
+
+		return reconcileShoppingList(tx, id)
+	})
+}
+
+// DiscardGroceryItem permanently removes an item that's still in the
+// inventory, with its history, as if it had never been added. It's for
+// undoing an add, everything else goes through the trash.
+func (s *GormStore) DiscardGroceryItem(id uint) error {
+	db := s.db
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Where("deleted_at IS NULL").Delete(&GroceryItem{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return errors.New("There's no grocery item with that id.")
+		}
+
+		return purgeItemRecords(tx, []uint{id})
+	})
+}
+
+// RestoreGroceryItem brings back a deleted item, unless another item has
+// taken its name in the meantime.
+func (s *GormStore) RestoreGroceryItem(id uint) (GroceryItem, error) {
+	db := s.db
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var item GroceryItem
+		if err := tx.Unscoped().Where("deleted_at IS NOT NULL").First(&item, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("There's no deleted grocery item with that id.")
+			}
+			return err
+		}
+
+		var clashes int64
+		if err := tx.Model(&GroceryItem{}).Where("name = ?", item.Name).Count(&clashes).Error; err != nil {
+			return err
+		}
+
+		if clashes > 0 {
+			return fmt.Errorf("There's already a grocery item called %s.", item.Name)
+		}
+
+		if err := tx.Unscoped().Model(&item).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+
+		if err := recordEvent(tx, item, EventRestore, item.Count, "restored"); err != nil {
+			return err
+		}
+
+		return reconcileShoppingList(tx, id)
+	})
+
+	if err != nil {
+		return GroceryItem{}, err
+	}
+
+	return s.GetGroceryItemByID(id)
+}
+
+// GetDeletedGroceryItems lists the items in the trash, most recently deleted
+// first.
+func (s *GormStore) GetDeletedGroceryItems() ([]GroceryItem, error) {
+	db := s.db
+
+	var items []GroceryItem
+	result := db.Unscoped().Preload("Category").Preload("Location").Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Find(&items)
+
+	return items, result.Error
+}
+
+// PurgeGroceryItem permanently removes a deleted item along with its history.
+func (s *GormStore) PurgeGroceryItem(id uint) error {
+	db := s.db
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Where("deleted_at IS NOT NULL").Delete(&GroceryItem{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return errors.New("There's no deleted grocery item with that id.")
+		}
+
+		return purgeItemRecords(tx, []uint{id})
+	})
+}
+
+// PurgeDeletedGroceryItems permanently removes items deleted before cutoff
+// and reports how many there were.
+func (s *GormStore) PurgeDeletedGroceryItems(cutoff time.Time) (int, error) {
+	db := s.db
+
+	var ids []uint
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&GroceryItem{}).Where("deleted_at < ?", cutoff).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+
+		if len(ids) == 0 {
+			return nil
+		}
+
+		if err := tx.Unscoped().Delete(&GroceryItem{}, ids).Error; err != nil {
+			return err
+		}
+
+		return purgeItemRecords(tx, ids)
+	})
+
+	return len(ids), err
+}
+
+// purgeItemRecords removes what refers to items that are gone for good.
+func purgeItemRecords(tx *gorm.DB, ids []uint) error {
+	if err := tx.Where("grocery_item_id IN ?", ids).Delete(&InventoryEvent{}).Error; err != nil {
+		return err
+	}
+
+	return tx.Unscoped().Where("grocery_item_id IN ?", ids).Delete(&ShoppingListItem{}).Error
+}
+
+// WipeGroceryItems removes every item from the inventory.
+func (s *GormStore) WipeGroceryItems() error {
+	db := s.db
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var items []GroceryItem
+		if err := tx.Find(&items).Error; err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			if err := recordEvent(tx, item, EventDelete, -item.Count, "inventory wiped"); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("1 = 1").Delete(&GroceryItem{}).Error; err != nil {
+			return err
+		}
+
+		// Nothing is left to be short of.
+		return tx.Where("grocery_item_id IS NOT NULL").Delete(&ShoppingListItem{}).Error
+	})
+}
+
+type ShoppingListItem struct {
+	Model
+	Name    string `json:"name"`
+	Checked bool   `json:"checked"`
+	// Quantity and Unit are only set on entries added to cover a shortfall.
+	Quantity float64 `json:"quantity"`
+	Unit     string  `json:"unit"`
+	// GroceryItemID links an entry to the inventory item whose par level
+	// added it.
+	GroceryItemID *uint `gorm:"index" json:"groceryItemId"`
+}
+
+func (s *GormStore) GetShoppingListItems() ([]ShoppingListItem, error) {
+	db := s.db
+
+	var items []ShoppingListItem
+	result := db.Order("id").Find(&items)
+
+	return items, result.Error
+}
+
+func (s *GormStore) CreateShoppingListItem(itemName string) (ShoppingListItem, error) {
+	name := normalizeName(itemName)
+
+	if len(name) <= 0 {
+		return ShoppingListItem{}, errors.New("Please type a grocery item.")
+	}
+
+	db := s.db
+	item := ShoppingListItem{Name: name}
+
+	result := db.Create(&item)
+
+	return item, result.Error
+}
+
+func (s *GormStore) SetShoppingListItemChecked(id uint, checked bool) error {
+	db := s.db
+
+	result := db.Model(&ShoppingListItem{}).Where("id = ?", id).Update("checked", checked)
+
+	if result.Error == nil && result.RowsAffected == 0 {
+		return errors.New("There's no list item with that id.")
+	}
+
+	return result.Error
+}
+
+func (s *GormStore) DeleteShoppingListItem(id uint) error {
+	db := s.db
+
+	result := db.Delete(&ShoppingListItem{}, id)
+
+	if result.Error == nil && result.RowsAffected == 0 {
+		return errors.New("There's no list item with that id.")
+	}
+
+	return result.Error
+}
+
+// WipeShoppingList removes every entry from the grocery list.
+func (s *GormStore) WipeShoppingList() error {
+	db := s.db
+
+	result := db.Where("1 = 1").Delete(&ShoppingListItem{})
 
-	return "Item removed.", result.Error
+	return result.Error
 }
@@ -0,0 +1,33 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// PathEnv names the environment variable that points chef at a database file.
+const PathEnv = "CHEF_DB"
+
+// ResolvePath picks the database file to open. An explicit path (from the
+// --db flag) wins, then $CHEF_DB, then chef.db in the XDG data directory.
+// Relative paths are made absolute so it's clear which file is in use.
+func ResolvePath(explicit string) (string, error) {
+	if explicit != "" {
+		return filepath.Abs(explicit)
+	}
+
+	if path := os.Getenv(PathEnv); path != "" {
+		return filepath.Abs(path)
+	}
+
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+
+	return filepath.Join(dataHome, "chef", "chef.db"), nil
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// reconcileShoppingList keeps the grocery list in step with an item's par
+// level. While the item is below its MinCount the shortfall sits on the list;
+// once it's back up to par, or in the trash, the entry is taken off again.
+// Entries added by hand aren't linked to an item and are left alone.
+func reconcileShoppingList(tx *gorm.DB, id uint) error {
+	var item GroceryItem
+	if err := tx.Unscoped().First(&item, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("There's no grocery item with that id.")
+		}
+		return err
+	}
+
+	var entry ShoppingListItem
+	result := tx.Where("grocery_item_id = ?", id).Limit(1).Find(&entry)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	shortfall := item.MinCount - item.Count
+	if item.DeletedAt.Valid {
+		shortfall = 0
+	}
+
+	if shortfall <= 0 {
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		return tx.Delete(&entry).Error
+	}
+
+	if result.RowsAffected == 0 {
+		entry = ShoppingListItem{
+			Name:          item.Name,
+			Quantity:      shortfall,
+			Unit:          item.Unit,
+			GroceryItemID: &item.ID,
+		}
+		return tx.Create(&entry).Error
+	}
+
+	return tx.Model(&entry).Updates(map[string]any{
+		"name":     item.Name,
+		"quantity": shortfall,
+		"unit":     item.Unit,
+	}).Error
+}
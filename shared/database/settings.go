@@ -0,0 +1,70 @@
+package database
+
+import (
+	"strconv"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// Keys for the values stored in the settings table.
+const (
+	SettingDefaultCount  = "default_count"
+	SettingConfirmDelete = "confirm_delete"
+	SettingTheme         = "theme"
+	SettingExpiryWindow  = "expiry_window_days"
+	SettingTrashDays     = "trash_retention_days"
+	SettingAutoBackup    = "auto_backup"
+	SettingBackupKeep    = "backup_keep"
+	SettingBackupDir     = "backup_dir"
+)
+
+type Setting struct {
+	Key       string    `gorm:"primaryKey" json:"key"`
+	Value     string    `json:"value"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (s *GormStore) GetSettings() (map[string]string, error) {
+	db := s.db
+
+	var settings []Setting
+	result := db.Find(&settings)
+
+	values := map[string]string{}
+	for _, setting := range settings {
+		values[setting.Key] = setting.Value
+	}
+
+	return values, result.Error
+}
+
+func (s *GormStore) SetSetting(key string, value string) error {
+	db := s.db
+
+	setting := Setting{Key: key, Value: value}
+	result := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+	}).Create(&setting)
+
+	return result.Error
+}
+
+// SettingInt reads an integer setting, falling back when it is missing or malformed.
+func SettingInt(values map[string]string, key string, fallback int) int {
+	value, err := strconv.Atoi(values[key])
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// SettingBool reads a boolean setting, falling back when it is missing or malformed.
+func SettingBool(values map[string]string, key string, fallback bool) bool {
+	value, err := strconv.ParseBool(values[key])
+	if err != nil {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,416 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lundjrl/go-bubble-tea-playground/shared/units"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SnapshotVersion is the format ExportSnapshot writes. Bump it when a change
+// to Snapshot would confuse an older chef reading it.
+const SnapshotVersion = 1
+
+// Snapshot is everything in a store, for moving it between machines or
+// recovering it. Items include the ones in the trash. IDs are only
+// meaningful within the snapshot, to link records to each other.
+type Snapshot struct {
+	Version      int                `json:"version"`
+	ExportedAt   time.Time          `json:"exportedAt"`
+	Categories   []Category         `json:"categories"`
+	Locations    []Location         `json:"locations"`
+	Items        []GroceryItem      `json:"items"`
+	Events       []InventoryEvent   `json:"events"`
+	ShoppingList []ShoppingListItem `json:"shoppingList"`
+	Settings     map[string]string  `json:"settings"`
+}
+
+var eventKinds = []EventKind{EventAdd, EventConsume, EventAdjust, EventEdit, EventDelete, EventRestore, EventUndo, EventRedo}
+
+// Validate checks that a snapshot can be imported: it's a version this chef
+// reads, every record makes sense and every reference points at a record in
+// the snapshot. Names and units are normalized the way the store saves them.
+func (s *Snapshot) Validate() error {
+	if s.Version <= 0 {
+		return errors.New("This isn't a chef backup, it has no version.")
+	}
+	if s.Version > SnapshotVersion {
+		return fmt.Errorf("This backup is version %d but this chef only reads up to version %d. Please upgrade chef.", s.Version, SnapshotVersion)
+	}
+
+	categories := map[uint]bool{}
+	categoryNames := map[string]bool{}
+	for i := range s.Categories {
+		category := &s.Categories[i]
+		category.Name = normalizeName(category.Name)
+		if category.ID == 0 || categories[category.ID] {
+			return fmt.Errorf("Category %q has a missing or repeated id.", category.Name)
+		}
+		if category.Name == "" || categoryNames[category.Name] {
+			return fmt.Errorf("Category %d has an empty or repeated name.", category.ID)
+		}
+		categories[category.ID] = true
+		categoryNames[category.Name] = true
+	}
+
+	parents := map[uint]*uint{}
+	for i := range s.Locations {
+		location := &s.Locations[i]
+		location.Name = strings.Join(strings.Fields(location.Name), " ")
+		location.Parent, location.Children = nil, nil
+		if _, seen := parents[location.ID]; location.ID == 0 || seen {
+			return fmt.Errorf("Location %q has a missing or repeated id.", location.Name)
+		}
+		if location.Name == "" {
+			return fmt.Errorf("Location %d has no name.", location.ID)
+		}
+		parents[location.ID] = location.ParentID
+	}
+	for id, parentID := range parents {
+		for steps := 0; parentID != nil; steps++ {
+			if !hasKey(parents, *parentID) {
+				return fmt.Errorf("Location %d is inside location %d, which isn't in the backup.", id, *parentID)
+			}
+			if steps > len(parents) {
+				return fmt.Errorf("Location %d is inside itself.", id)
+			}
+			parentID = parents[*parentID]
+		}
+	}
+
+	items := map[uint]bool{}
+	liveNames := map[string]bool{}
+	for i := range s.Items {
+		item := &s.Items[i]
+		item.Name = normalizeName(item.Name)
+		item.Unit = units.Canonical(item.Unit)
+		item.Category, item.Location = nil, nil
+		if item.ID == 0 || items[item.ID] {
+			return fmt.Errorf("Item %q has a missing or repeated id.", item.Name)
+		}
+		if item.Name == "" {
+			return fmt.Errorf("Item %d has no name.", item.ID)
+		}
+		if item.Count < 0 || item.MinCount < 0 {
+			return fmt.Errorf("%s has a negative count.", item.Name)
+		}
+		if item.CategoryID != nil && !categories[*item.CategoryID] {
+			return fmt.Errorf("%s is in category %d, which isn't in the backup.", item.Name, *item.CategoryID)
+		}
+		if item.LocationID != nil && !hasKey(parents, *item.LocationID) {
+			return fmt.Errorf("%s is kept in location %d, which isn't in the backup.", item.Name, *item.LocationID)
+		}
+		if !item.DeletedAt.Valid {
+			if liveNames[item.Name] {
+				return fmt.Errorf("%s is in the backup twice.", item.Name)
+			}
+			liveNames[item.Name] = true
+		}
+		items[item.ID] = true
+	}
+
+	events := map[uint]bool{}
+	for _, event := range s.Events {
+		if event.ID == 0 || events[event.ID] {
+			return errors.New("An inventory event has a missing or repeated id.")
+		}
+		if !items[event.GroceryItemID] {
+			return fmt.Errorf("Inventory event %d is for item %d, which isn't in the backup.", event.ID, event.GroceryItemID)
+		}
+		if !slices.Contains(eventKinds, event.Kind) {
+			return fmt.Errorf("Inventory event %d has an unknown kind %q.", event.ID, event.Kind)
+		}
+		events[event.ID] = true
+	}
+
+	entries := map[uint]bool{}
+	for i := range s.ShoppingList {
+		entry := &s.ShoppingList[i]
+		entry.Name = normalizeName(entry.Name)
+		if entry.ID == 0 || entries[entry.ID] {
+			return fmt.Errorf("List entry %q has a missing or repeated id.", entry.Name)
+		}
+		if entry.Name == "" {
+			return fmt.Errorf("List entry %d has no name.", entry.ID)
+		}
+		if entry.GroceryItemID != nil && !items[*entry.GroceryItemID] {
+			return fmt.Errorf("List entry %s is for item %d, which isn't in the backup.", entry.Name, *entry.GroceryItemID)
+		}
+		entries[entry.ID] = true
+	}
+
+	if s.Settings == nil {
+		s.Settings = map[string]string{}
+	}
+
+	return nil
+}
+
+func hasKey[V any](m map[uint]V, key uint) bool {
+	_, ok := m[key]
+	return ok
+}
+
+// idAllocator hands out IDs above every ID already in a table.
+type idAllocator uint
+
+func (a *idAllocator) next() uint {
+	*a++
+	return uint(*a)
+}
+
+func allocatorAbove[T any](records []T, id func(T) uint) idAllocator {
+	highest := uint(0)
+	for _, record := range records {
+		highest = max(highest, id(record))
+	}
+	return idAllocator(highest)
+}
+
+// mergeSnapshots adds what's only in incoming to current. Anything both have
+// is kept as it is in current: items with the same name, categories with the
+// same name, locations at the same path, list entries with the same name and
+// settings with the same key. Items in incoming's trash aren't brought over.
+// Both snapshots must be valid.
+func mergeSnapshots(current Snapshot, incoming Snapshot) (Snapshot, int) {
+	merged := current
+	merged.Categories = slices.Clone(current.Categories)
+	merged.Locations = slices.Clone(current.Locations)
+	merged.Items = slices.Clone(current.Items)
+	merged.Events = slices.Clone(current.Events)
+	merged.ShoppingList = slices.Clone(current.ShoppingList)
+	merged.Settings = map[string]string{}
+	for key, value := range current.Settings {
+		merged.Settings[key] = value
+	}
+
+	categoryIDs := allocatorAbove(merged.Categories, func(c Category) uint { return c.ID })
+	categories := map[uint]uint{}
+	for _, category := range incoming.Categories {
+		i := slices.IndexFunc(merged.Categories, func(c Category) bool { return c.Name == category.Name })
+		if i >= 0 {
+			categories[category.ID] = merged.Categories[i].ID
+			continue
+		}
+		oldID := category.ID
+		category.ID = categoryIDs.next()
+		categories[oldID] = category.ID
+		merged.Categories = append(merged.Categories, category)
+	}
+
+	// Parents are placed before their children, so a location's parent
+	// already has its merged ID when the location is matched.
+	locationIDs := allocatorAbove(merged.Locations, func(l Location) uint { return l.ID })
+	locations := map[uint]uint{}
+	for placed := true; placed; {
+		placed = false
+		for _, location := range incoming.Locations {
+			if _, done := locations[location.ID]; done || location.DeletedAt.Valid {
+				continue
+			}
+
+			var parentID *uint
+			if location.ParentID != nil {
+				id, ok := locations[*location.ParentID]
+				if !ok {
+					continue
+				}
+				parentID = &id
+			}
+
+			oldID := location.ID
+			i := slices.IndexFunc(merged.Locations, func(l Location) bool {
+				return !l.DeletedAt.Valid && strings.EqualFold(l.Name, location.Name) && sameID(l.ParentID, parentID)
+			})
+			if i >= 0 {
+				locations[oldID] = merged.Locations[i].ID
+			} else {
+				location.ID = locationIDs.next()
+				location.ParentID = parentID
+				locations[oldID] = location.ID
+				merged.Locations = append(merged.Locations, location)
+			}
+			placed = true
+		}
+	}
+
+	itemIDs := allocatorAbove(merged.Items, func(item GroceryItem) uint { return item.ID })
+	items := map[uint]uint{}
+	for _, item := range incoming.Items {
+		if item.DeletedAt.Valid {
+			continue
+		}
+		if slices.ContainsFunc(merged.Items, func(existing GroceryItem) bool {
+			return !existing.DeletedAt.Valid && existing.Name == item.Name
+		}) {
+			continue
+		}
+
+		oldID := item.ID
+		item.ID = itemIDs.next()
+		items[oldID] = item.ID
+		if item.CategoryID != nil {
+			id := categories[*item.CategoryID]
+			item.CategoryID = &id
+		}
+		if item.LocationID != nil {
+			// A live item can only be somewhere that still exists.
+			if id, ok := locations[*item.LocationID]; ok {
+				item.LocationID = &id
+			} else {
+				item.LocationID = nil
+			}
+		}
+		merged.Items = append(merged.Items, item)
+	}
+
+	eventIDs := allocatorAbove(merged.Events, func(e InventoryEvent) uint { return e.ID })
+	for _, event := range incoming.Events {
+		id, ok := items[event.GroceryItemID]
+		if !ok {
+			continue
+		}
+		event.ID = eventIDs.next()
+		event.GroceryItemID = id
+		merged.Events = append(merged.Events, event)
+	}
+
+	entryIDs := allocatorAbove(merged.ShoppingList, func(e ShoppingListItem) uint { return e.ID })
+	for _, entry := range incoming.ShoppingList {
+		if entry.GroceryItemID != nil {
+			id, ok := items[*entry.GroceryItemID]
+			if !ok {
+				continue
+			}
+			entry.GroceryItemID = &id
+		} else if slices.ContainsFunc(merged.ShoppingList, func(existing ShoppingListItem) bool { return existing.Name == entry.Name }) {
+			continue
+		}
+		entry.ID = entryIDs.next()
+		merged.ShoppingList = append(merged.ShoppingList, entry)
+	}
+
+	for key, value := range incoming.Settings {
+		if _, ok := merged.Settings[key]; !ok {
+			merged.Settings[key] = value
+		}
+	}
+
+	return merged, len(items)
+}
+
+func (s *GormStore) ExportSnapshot() (Snapshot, error) {
+	return exportSnapshot(s.db)
+}
+
+func exportSnapshot(tx *gorm.DB) (Snapshot, error) {
+	snapshot := Snapshot{Version: SnapshotVersion, ExportedAt: time.Now(), Settings: map[string]string{}}
+
+	// Items in the trash can still point at deleted locations, so those come
+	// along too.
+	if err := tx.Order("id").Find(&snapshot.Categories).Error; err != nil {
+		return snapshot, err
+	}
+	if err := tx.Unscoped().Order("id").Find(&snapshot.Locations).Error; err != nil {
+		return snapshot, err
+	}
+	if err := tx.Unscoped().Order("id").Find(&snapshot.Items).Error; err != nil {
+		return snapshot, err
+	}
+	if err := tx.Order("id").Find(&snapshot.Events).Error; err != nil {
+		return snapshot, err
+	}
+	if err := tx.Order("id").Find(&snapshot.ShoppingList).Error; err != nil {
+		return snapshot, err
+	}
+
+	var settings []Setting
+	if err := tx.Find(&settings).Error; err != nil {
+		return snapshot, err
+	}
+	for _, setting := range settings {
+		snapshot.Settings[setting.Key] = setting.Value
+	}
+
+	return snapshot, nil
+}
+
+// ImportSnapshot loads a snapshot in one transaction, so a bad one leaves the
+// store as it was. With replace, everything in the store is swapped for the
+// snapshot. Otherwise the snapshot is merged in, see mergeSnapshots. It
+// reports how many items were brought in.
+func (s *GormStore) ImportSnapshot(snapshot Snapshot, replace bool) (int, error) {
+	if err := snapshot.Validate(); err != nil {
+		return 0, err
+	}
+
+	imported := 0
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if replace {
+			imported = liveItemCount(snapshot.Items)
+		} else {
+			current, err := exportSnapshot(tx)
+			if err != nil {
+				return err
+			}
+			snapshot, imported = mergeSnapshots(current, snapshot)
+		}
+
+		for _, table := range []string{"inventory_events", "shopping_list_items", "grocery_items", "locations", "categories", "settings"} {
+			if err := tx.Exec("DELETE FROM `" + table + "`").Error; err != nil {
+				return err
+			}
+		}
+
+		if len(snapshot.Categories) > 0 {
+			if err := tx.Omit(clause.Associations).Create(&snapshot.Categories).Error; err != nil {
+				return err
+			}
+		}
+		if len(snapshot.Locations) > 0 {
+			if err := tx.Omit(clause.Associations).Create(&snapshot.Locations).Error; err != nil {
+				return err
+			}
+		}
+		if len(snapshot.Items) > 0 {
+			if err := tx.Omit(clause.Associations).Create(&snapshot.Items).Error; err != nil {
+				return err
+			}
+		}
+		if len(snapshot.Events) > 0 {
+			if err := tx.Omit(clause.Associations).Create(&snapshot.Events).Error; err != nil {
+				return err
+			}
+		}
+		if len(snapshot.ShoppingList) > 0 {
+			if err := tx.Omit(clause.Associations).Create(&snapshot.ShoppingList).Error; err != nil {
+				return err
+			}
+		}
+		for key, value := range snapshot.Settings {
+			if err := tx.Create(&Setting{Key: key, Value: value}).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return imported, err
+}
+
+func liveItemCount(items []GroceryItem) int {
+	count := 0
+	for _, item := range items {
+		if !item.DeletedAt.Valid {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,365 @@
+package database
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func uintPtr(id uint) *uint {
+	return &id
+}
+
+// validSnapshot links one of everything together.
+func validSnapshot() Snapshot {
+	return Snapshot{
+		Version:    SnapshotVersion,
+		Categories: []Category{{Model: Model{ID: 1}, Name: "Dairy"}},
+		Locations: []Location{
+			{Model: Model{ID: 1}, Name: "Kitchen"},
+			{Model: Model{ID: 2}, Name: " Fridge ", ParentID: uintPtr(1)},
+		},
+		Items: []GroceryItem{
+			{Model: Model{ID: 1}, Name: "Milk", Count: 1, Unit: "Litres", CategoryID: uintPtr(1), LocationID: uintPtr(2), MinCount: 2},
+			{Model: Model{ID: 2, DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}}, Name: "milk", Count: 3},
+		},
+		Events:       []InventoryEvent{{ID: 1, GroceryItemID: 1, Kind: EventAdd, Delta: 1}},
+		ShoppingList: []ShoppingListItem{{Model: Model{ID: 1}, Name: "Milk", Quantity: 1, GroceryItemID: uintPtr(1)}},
+	}
+}
+
+func TestSnapshotValidate(t *testing.T) {
+	snapshot := validSnapshot()
+	if err := snapshot.Validate(); err != nil {
+		t.Fatalf("Validate() = %v on a valid snapshot", err)
+	}
+
+	if snapshot.Items[0].Name != "milk" || snapshot.Items[0].Unit != "l" || snapshot.Locations[1].Name != "Fridge" {
+		t.Errorf("Validate() didn't normalize names and units: %+v, %+v", snapshot.Items[0], snapshot.Locations[1])
+	}
+	if snapshot.Settings == nil {
+		t.Error("Validate() left Settings nil")
+	}
+
+	tests := []struct {
+		name   string
+		breaks func(s *Snapshot)
+	}{
+		{"no version", func(s *Snapshot) { s.Version = 0 }},
+		{"newer version", func(s *Snapshot) { s.Version = SnapshotVersion + 1 }},
+		{"repeated category id", func(s *Snapshot) { s.Categories = append(s.Categories, Category{Model: Model{ID: 1}, Name: "meat"}) }},
+		{"repeated category name", func(s *Snapshot) { s.Categories = append(s.Categories, Category{Model: Model{ID: 2}, Name: "dairy"}) }},
+		{"missing parent location", func(s *Snapshot) { s.Locations[1].ParentID = uintPtr(9) }},
+		{"location inside itself", func(s *Snapshot) { s.Locations[0].ParentID = uintPtr(2) }},
+		{"unnamed item", func(s *Snapshot) { s.Items[0].Name = "  " }},
+		{"negative count", func(s *Snapshot) { s.Items[0].Count = -1 }},
+		{"missing category", func(s *Snapshot) { s.Items[0].CategoryID = uintPtr(9) }},
+		{"missing location", func(s *Snapshot) { s.Items[0].LocationID = uintPtr(9) }},
+		{"two live items with one name", func(s *Snapshot) { s.Items[1].DeletedAt = gorm.DeletedAt{} }},
+		{"event for a missing item", func(s *Snapshot) { s.Events[0].GroceryItemID = 9 }},
+		{"unknown event kind", func(s *Snapshot) { s.Events[0].Kind = "teleport" }},
+		{"list entry for a missing item", func(s *Snapshot) { s.ShoppingList[0].GroceryItemID = uintPtr(9) }},
+		{"repeated list entry id", func(s *Snapshot) {
+			s.ShoppingList = append(s.ShoppingList, ShoppingListItem{Model: Model{ID: 1}, Name: "bananas"})
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			snapshot := validSnapshot()
+			test.breaks(&snapshot)
+			if err := snapshot.Validate(); err == nil {
+				t.Error("Validate() accepted it")
+			}
+		})
+	}
+}
+
+func TestMergeSnapshots(t *testing.T) {
+	current := Snapshot{
+		Version:    SnapshotVersion,
+		Categories: []Category{{Model: Model{ID: 1}, Name: "dairy"}},
+		Locations:  []Location{{Model: Model{ID: 4}, Name: "Pantry"}},
+		Items:      []GroceryItem{{Model: Model{ID: 1}, Name: "milk", Count: 1, CategoryID: uintPtr(1)}},
+		Events:     []InventoryEvent{{ID: 1, GroceryItemID: 1, Kind: EventAdd, Delta: 1}},
+		ShoppingList: []ShoppingListItem{
+			{Model: Model{ID: 1}, Name: "bananas"},
+		},
+		Settings: map[string]string{SettingTheme: "mocha"},
+	}
+
+	incoming := Snapshot{
+		Version: SnapshotVersion,
+		Categories: []Category{
+			{Model: Model{ID: 5}, Name: "dairy"},
+			{Model: Model{ID: 6}, Name: "snacks"},
+		},
+		Locations: []Location{
+			{Model: Model{ID: 8}, Name: "Shelf", ParentID: uintPtr(7)},
+			{Model: Model{ID: 7}, Name: "pantry"},
+		},
+		Items: []GroceryItem{
+			{Model: Model{ID: 7}, Name: "milk", Count: 9, CategoryID: uintPtr(5)},
+			{Model: Model{ID: 8}, Name: "chips", Count: 2, CategoryID: uintPtr(6), LocationID: uintPtr(8), MinCount: 3},
+			{Model: Model{ID: 9, DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}}, Name: "bread", Count: 1},
+		},
+		Events: []InventoryEvent{
+			{ID: 1, GroceryItemID: 7, Kind: EventAdd, Delta: 9},
+			{ID: 2, GroceryItemID: 8, Kind: EventAdd, Delta: 2},
+			{ID: 3, GroceryItemID: 9, Kind: EventDelete, Delta: -1},
+		},
+		ShoppingList: []ShoppingListItem{
+			{Model: Model{ID: 1}, Name: "chips", Quantity: 1, GroceryItemID: uintPtr(8)},
+			{Model: Model{ID: 2}, Name: "bananas"},
+			{Model: Model{ID: 3}, Name: "coffee"},
+		},
+		Settings: map[string]string{SettingTheme: "latte", SettingTrashDays: "7"},
+	}
+
+	for _, snapshot := range []*Snapshot{&current, &incoming} {
+		if err := snapshot.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	merged, imported := mergeSnapshots(current, incoming)
+
+	if imported != 1 {
+		t.Errorf("imported %d items, want only chips", imported)
+	}
+	if err := merged.Validate(); err != nil {
+		t.Fatalf("merged snapshot isn't valid: %v", err)
+	}
+
+	if len(merged.Categories) != 2 || merged.Categories[1] != (Category{Model: Model{ID: 2}, Name: "snacks"}) {
+		t.Errorf("categories = %+v, want dairy kept and snacks added as 2", merged.Categories)
+	}
+
+	// Pantry matches ignoring case, so Shelf is added inside the existing one.
+	if len(merged.Locations) != 2 || merged.Locations[1].Name != "Shelf" || *merged.Locations[1].ParentID != 4 {
+		t.Errorf("locations = %+v, want Shelf inside Pantry (4)", merged.Locations)
+	}
+	shelf := merged.Locations[1].ID
+
+	if len(merged.Items) != 2 {
+		t.Fatalf("items = %+v, want milk kept and chips added", merged.Items)
+	}
+	milk, chips := merged.Items[0], merged.Items[1]
+	if milk.Count != 1 {
+		t.Errorf("milk count = %v, want the current 1", milk.Count)
+	}
+	if chips.ID != 2 || *chips.CategoryID != 2 || *chips.LocationID != shelf {
+		t.Errorf("chips = id %d in category %d at %d, want id 2 in category 2 at %d", chips.ID, *chips.CategoryID, *chips.LocationID, shelf)
+	}
+
+	if len(merged.Events) != 2 || merged.Events[1].ID != 2 || merged.Events[1].GroceryItemID != 2 {
+		t.Errorf("events = %+v, want chips' add renumbered onto item 2", merged.Events)
+	}
+
+	names := []string{}
+	for _, entry := range merged.ShoppingList {
+		names = append(names, entry.Name)
+	}
+	if !slices.Equal(names, []string{"bananas", "chips", "coffee"}) {
+		t.Errorf("list = %v, want bananas once, chips and coffee", names)
+	}
+	if link := merged.ShoppingList[1].GroceryItemID; link == nil || *link != 2 {
+		t.Errorf("chips' list entry links to %v, want 2", link)
+	}
+
+	if merged.Settings[SettingTheme] != "mocha" || merged.Settings[SettingTrashDays] != "7" {
+		t.Errorf("settings = %v, want the current theme and the new trash days", merged.Settings)
+	}
+	if len(current.Items) != 1 || len(current.Settings) != 1 {
+		t.Error("mergeSnapshots changed current")
+	}
+}
+
+// fillStore gives a store some of everything a snapshot carries.
+func fillStore(t *testing.T, store InventoryStore) {
+	t.Helper()
+
+	categories, err := store.GetCategories()
+	if err != nil {
+		t.Fatal(err)
+	}
+	kitchen, err := store.CreateLocation("Kitchen", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fridge, err := store.CreateLocation("Fridge", &kitchen.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expires := time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)
+	milk := mustUpsert(t, store, GroceryItem{Name: "milk", Count: 1, Unit: "l", CategoryID: &categories[1].ID, LocationID: &fridge.ID, ExpiresAt: &expires, MinCount: 2})
+	if _, err := store.AdjustGroceryItemCount(milk.ID, 0.5); err != nil {
+		t.Fatal(err)
+	}
+	bread := mustUpsert(t, store, GroceryItem{Name: "bread", Count: 1, LocationID: &kitchen.ID})
+	if err := store.DeleteGroceryItemByID(bread.ID); err != nil {
+		t.Fatal(err)
+	}
+	mustUpsert(t, store, GroceryItem{Name: "eggs", Count: 12})
+
+	if _, err := store.CreateShoppingListItem("bananas"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetSetting(SettingTrashDays, "14"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// describeStore summarizes what's in a store without IDs or timestamps, which
+// aren't expected to survive a merge.
+func describeStore(t *testing.T, store InventoryStore) string {
+	t.Helper()
+
+	snapshot, err := store.ExportSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := []string{}
+	for _, location := range snapshot.Locations {
+		lines = append(lines, "location "+LocationPath(snapshot.Locations, location.ID))
+	}
+	for _, item := range snapshot.Items {
+		history := []string{}
+		for _, event := range snapshot.Events {
+			if event.GroceryItemID == item.ID {
+				history = append(history, fmt.Sprintf("%s %v", event.Kind, event.Delta))
+			}
+		}
+		where := ""
+		if item.LocationID != nil {
+			where = LocationPath(snapshot.Locations, *item.LocationID)
+		}
+		lines = append(lines, fmt.Sprintf("item %s %v %s min %v at %q expires %s deleted %v history %v",
+			item.Name, item.Count, item.Unit, item.MinCount, where, formatTime(item.ExpiresAt), item.DeletedAt.Valid, history))
+	}
+	for _, entry := range snapshot.ShoppingList {
+		lines = append(lines, fmt.Sprintf("list %s %v linked %v", entry.Name, entry.Quantity, entry.GroceryItemID != nil))
+	}
+	for _, category := range snapshot.Categories {
+		lines = append(lines, "category "+category.Name)
+	}
+	for key, value := range snapshot.Settings {
+		lines = append(lines, "setting "+key+"="+value)
+	}
+
+	slices.Sort(lines)
+	return strings.Join(lines, "\n")
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "never"
+	}
+	return t.UTC().Format(time.DateOnly)
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	for _, kind := range storeKinds {
+		t.Run(kind.name+"/replace", func(t *testing.T) {
+			source := kind.open(t)
+			fillStore(t, source)
+
+			snapshot, err := source.ExportSnapshot()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			target := kind.open(t)
+			mustUpsert(t, target, GroceryItem{Name: "old stuff", Count: 1})
+
+			imported, err := target.ImportSnapshot(snapshot, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if imported != 2 {
+				t.Errorf("imported %d items, want milk and eggs", imported)
+			}
+
+			if got, want := describeStore(t, target), describeStore(t, source); got != want {
+				t.Errorf("after replace:\n%s\nwant:\n%s", got, want)
+			}
+
+			again, err := target.ExportSnapshot()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(again.Items) != len(snapshot.Items) || again.Items[0].ID != snapshot.Items[0].ID {
+				t.Errorf("replace didn't keep the snapshot's IDs: %+v", again.Items)
+			}
+		})
+
+		t.Run(kind.name+"/merge", func(t *testing.T) {
+			source := kind.open(t)
+			fillStore(t, source)
+
+			snapshot, err := source.ExportSnapshot()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Merging a store's own snapshot back in changes nothing.
+			before := describeStore(t, source)
+			if imported, err := source.ImportSnapshot(snapshot, false); err != nil || imported != 0 {
+				t.Errorf("merging into itself = %d, %v, want nothing imported", imported, err)
+			}
+			if after := describeStore(t, source); after != before {
+				t.Errorf("merging into itself changed the store:\n%s\nwant:\n%s", after, before)
+			}
+
+			target := kind.open(t)
+			mustUpsert(t, target, GroceryItem{Name: "milk", Count: 4, Unit: "l"})
+			if err := target.SetSetting(SettingTrashDays, "3"); err != nil {
+				t.Fatal(err)
+			}
+
+			imported, err := target.ImportSnapshot(snapshot, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if imported != 1 {
+				t.Errorf("imported %d items, want only eggs", imported)
+			}
+
+			milk, err := target.GetGroceryItemByName("milk")
+			if err != nil || milk.Count != 4 {
+				t.Errorf("milk = %v, %v, want the target's 4 kept", milk.Count, err)
+			}
+			if _, err := target.GetGroceryItemByName("eggs"); err != nil {
+				t.Errorf("eggs weren't merged in: %v", err)
+			}
+			if trash, _ := target.GetDeletedGroceryItems(); len(trash) != 0 {
+				t.Errorf("merge brought over the trash: %+v", trash)
+			}
+			if values, _ := target.GetSettings(); values[SettingTrashDays] != "3" {
+				t.Errorf("trash days = %q, want the target's 3 kept", values[SettingTrashDays])
+			}
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		eachStore(t, func(t *testing.T, store InventoryStore) {
+			fillStore(t, store)
+			before := describeStore(t, store)
+
+			snapshot := validSnapshot()
+			snapshot.Events[0].Kind = "teleport"
+			if _, err := store.ImportSnapshot(snapshot, true); err == nil {
+				t.Fatal("importing an invalid snapshot succeeded")
+			}
+			if after := describeStore(t, store); after != before {
+				t.Errorf("a failed import changed the store:\n%s\nwant:\n%s", after, before)
+			}
+		})
+	})
+}
@@ -0,0 +1,56 @@
+package database
+
+import "time"
+
+// InventoryStore is everything chef reads and writes. GormStore keeps it in
+// SQLite, MemoryStore keeps it in memory for embedding and tests.
+type InventoryStore interface {
+	// Path is the database file in use, or empty for a store that isn't
+	// backed by a file.
+	Path() string
+
+	// Inventory
+	GetGroceryItems() ([]GroceryItem, error)
+	GetGroceryItemByName(name string) (GroceryItem, error)
+	GetGroceryItemByID(id uint) (GroceryItem, error)
+	UpsertGroceryItem(draft GroceryItem) (item GroceryItem, created bool, err error)
+	ImportGroceryItems(drafts []GroceryItem) (added int, err error)
+	UpdateGroceryItem(changes GroceryItem) (GroceryItem, error)
+	RevertGroceryItem(state GroceryItem, kind EventKind) (GroceryItem, error)
+	AdjustGroceryItemCount(id uint, delta float64) (GroceryItem, error)
+	DeleteGroceryItemByID(id uint) error
+	DiscardGroceryItem(id uint) error
+	RestoreGroceryItem(id uint) (GroceryItem, error)
+	GetDeletedGroceryItems() ([]GroceryItem, error)
+	PurgeGroceryItem(id uint) error
+	PurgeDeletedGroceryItems(cutoff time.Time) (int, error)
+	WipeGroceryItems() error
+	GetInventoryEvents(itemID uint) ([]InventoryEvent, error)
+
+	// Grocery list
+	GetShoppingListItems() ([]ShoppingListItem, error)
+	CreateShoppingListItem(name string) (ShoppingListItem, error)
+	SetShoppingListItemChecked(id uint, checked bool) error
+	DeleteShoppingListItem(id uint) error
+	WipeShoppingList() error
+
+	// Categories and locations
+	GetCategories() ([]Category, error)
+	CreateCategory(name string) (Category, error)
+	GetLocations() ([]Location, error)
+	CreateLocation(name string, parentID *uint) (Location, error)
+	DeleteLocation(id uint) error
+
+	// Settings
+	GetSettings() (map[string]string, error)
+	SetSetting(key string, value string) error
+
+	// Backups
+	ExportSnapshot() (Snapshot, error)
+	ImportSnapshot(snapshot Snapshot, replace bool) (int, error)
+}
+
+var (
+	_ InventoryStore = (*GormStore)(nil)
+	_ InventoryStore = (*MemoryStore)(nil)
+)
@@ -0,0 +1,489 @@
+package database
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// storeKinds opens an empty store of each kind: a GormStore on a temporary
+// file and a MemoryStore.
+var storeKinds = []struct {
+	name string
+	open func(t *testing.T) InventoryStore
+}{
+	{"gorm", func(t *testing.T) InventoryStore {
+		store, err := OpenGormStore(filepath.Join(t.TempDir(), "chef.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return store
+	}},
+	{"memory", func(t *testing.T) InventoryStore {
+		return NewMemoryStore()
+	}},
+}
+
+// eachStore runs test against every kind of store, so they keep following
+// the same rules.
+func eachStore(t *testing.T, test func(t *testing.T, store InventoryStore)) {
+	t.Helper()
+
+	for _, kind := range storeKinds {
+		t.Run(kind.name, func(t *testing.T) {
+			test(t, kind.open(t))
+		})
+	}
+}
+
+func mustUpsert(t *testing.T, store InventoryStore, draft GroceryItem) GroceryItem {
+	t.Helper()
+
+	item, _, err := store.UpsertGroceryItem(draft)
+	if err != nil {
+		t.Fatalf("UpsertGroceryItem(%s): %v", draft.Name, err)
+	}
+	return item
+}
+
+// shortfall returns the quantity on the grocery list for an item's par
+// level, and whether it's there at all.
+func shortfall(t *testing.T, store InventoryStore, id uint) (float64, bool) {
+	t.Helper()
+
+	entries, err := store.GetShoppingListItems()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.GroceryItemID != nil && *entry.GroceryItemID == id {
+			return entry.Quantity, true
+		}
+	}
+	return 0, false
+}
+
+func TestStoreUpsert(t *testing.T) {
+	eachStore(t, func(t *testing.T, store InventoryStore) {
+		item, created, err := store.UpsertGroceryItem(GroceryItem{Name: " Milk ", Count: 1, Unit: "litre"})
+		if err != nil || !created {
+			t.Fatalf("UpsertGroceryItem() = %v, %v, want a new item", created, err)
+		}
+		if item.Name != "milk" || item.Unit != "l" {
+			t.Errorf("item = %q in %q, want milk in l", item.Name, item.Unit)
+		}
+
+		item, created, err = store.UpsertGroceryItem(GroceryItem{Name: "milk", Count: 500, Unit: "ml"})
+		if err != nil || created {
+			t.Fatalf("restock = %v, %v, want the existing item", created, err)
+		}
+		if item.Count != 1.5 || item.Unit != "l" {
+			t.Errorf("restocked to %v %s, want 1.5 l", item.Count, item.Unit)
+		}
+
+		if _, _, err := store.UpsertGroceryItem(GroceryItem{Name: "milk", Count: 200, Unit: "g"}); err == nil {
+			t.Error("restocking milk by weight succeeded, want an error")
+		}
+
+		item = mustUpsert(t, store, GroceryItem{Name: "milk", Count: 1, Unit: "l", MinCount: 5})
+		if item.MinCount != 5 {
+			t.Errorf("restocking with a minimum left it at %v, want 5", item.MinCount)
+		}
+		if quantity, _ := shortfall(t, store, item.ID); quantity != 2.5 {
+			t.Errorf("shortfall = %v, want 2.5", quantity)
+		}
+		if item = mustUpsert(t, store, GroceryItem{Name: "milk", Count: 1, Unit: "l"}); item.MinCount != 5 {
+			t.Errorf("restocking without a minimum changed it to %v", item.MinCount)
+		}
+
+		flour := mustUpsert(t, store, GroceryItem{Name: "flour", Count: 0})
+		flour = mustUpsert(t, store, GroceryItem{Name: "flour", Count: 500, Unit: "g"})
+		if flour.Count != 500 || flour.Unit != "g" {
+			t.Errorf("empty flour restocked to %v %s, want 500 g", flour.Count, flour.Unit)
+		}
+
+		if _, _, err := store.UpsertGroceryItem(GroceryItem{Name: "  "}); err == nil {
+			t.Error("adding a blank name succeeded")
+		}
+	})
+}
+
+func TestStoreImportGroceryItems(t *testing.T) {
+	eachStore(t, func(t *testing.T, store InventoryStore) {
+		mustUpsert(t, store, GroceryItem{Name: "milk", Count: 1, Unit: "l"})
+
+		_, err := store.ImportGroceryItems([]GroceryItem{
+			{Name: "eggs", Count: 12, MinCount: 6},
+			{Name: "milk", Count: 500, Unit: "ml"},
+			{Name: "milk", Count: 200, Unit: "g"},
+		})
+		if err == nil {
+			t.Fatal("importing milk by weight succeeded, want an error")
+		}
+
+		items, err := store.GetGroceryItems()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(items) != 1 || items[0].Count != 1 {
+			t.Errorf("items after a failed import = %+v, want milk untouched", items)
+		}
+		if events, _ := store.GetInventoryEvents(items[0].ID); len(events) != 1 {
+			t.Errorf("a failed import left %d events on milk, want only the add", len(events))
+		}
+		if entries, _ := store.GetShoppingListItems(); len(entries) != 0 {
+			t.Errorf("a failed import left %+v on the grocery list", entries)
+		}
+
+		added, err := store.ImportGroceryItems([]GroceryItem{
+			{Name: "eggs", Count: 12},
+			{Name: "milk", Count: 500, Unit: "ml", MinCount: 2},
+		})
+		if err != nil || added != 1 {
+			t.Fatalf("ImportGroceryItems() = %d, %v, want eggs added", added, err)
+		}
+		if quantity, _ := shortfall(t, store, items[0].ID); quantity != 0.5 {
+			t.Errorf("milk shortfall = %v, want 0.5", quantity)
+		}
+	})
+}
+
+func TestStoreUpdate(t *testing.T) {
+	eachStore(t, func(t *testing.T, store InventoryStore) {
+		milk := mustUpsert(t, store, GroceryItem{Name: "milk", Count: 1})
+		mustUpsert(t, store, GroceryItem{Name: "eggs", Count: 12})
+
+		milk.Name = "Eggs"
+		if _, err := store.UpdateGroceryItem(milk); err == nil {
+			t.Error("renaming milk to eggs succeeded, want a clash")
+		}
+
+		milk.Name = "oat milk"
+		milk.Count = 2
+		updated, err := store.UpdateGroceryItem(milk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if updated.Name != "oat milk" || updated.Count != 2 {
+			t.Errorf("updated = %q x%v, want oat milk x2", updated.Name, updated.Count)
+		}
+
+		events, err := store.GetInventoryEvents(milk.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != 2 || events[0].Kind != EventAdjust || events[0].Delta != 1 {
+			t.Errorf("events = %+v, want an adjustment of 1 on top of the add", events)
+		}
+
+		milk.Count = -1
+		if _, err := store.UpdateGroceryItem(milk); err == nil {
+			t.Error("saving a negative count succeeded")
+		}
+	})
+}
+
+func TestStoreAdjustStopsAtZero(t *testing.T) {
+	eachStore(t, func(t *testing.T, store InventoryStore) {
+		eggs := mustUpsert(t, store, GroceryItem{Name: "eggs", Count: 3})
+
+		eggs, err := store.AdjustGroceryItemCount(eggs.ID, -5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if eggs.Count != 0 {
+			t.Errorf("count = %v, want 0", eggs.Count)
+		}
+
+		events, err := store.GetInventoryEvents(eggs.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if events[0].Kind != EventConsume || events[0].Delta != -3 {
+			t.Errorf("latest event = %s %v, want consume -3", events[0].Kind, events[0].Delta)
+		}
+
+		if _, err := store.AdjustGroceryItemCount(eggs.ID, -1); err != nil {
+			t.Fatal(err)
+		}
+		if events, _ := store.GetInventoryEvents(eggs.ID); len(events) != 2 {
+			t.Errorf("adjusting an empty item recorded an event, have %d", len(events))
+		}
+	})
+}
+
+func TestStoreTrash(t *testing.T) {
+	eachStore(t, func(t *testing.T, store InventoryStore) {
+		categories, err := store.GetCategories()
+		if err != nil || len(categories) == 0 {
+			t.Fatalf("GetCategories() = %d, %v, want the defaults", len(categories), err)
+		}
+		location, err := store.CreateLocation("Fridge", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		milk := mustUpsert(t, store, GroceryItem{Name: "milk", Count: 1, CategoryID: &categories[0].ID, LocationID: &location.ID})
+		if err := store.DeleteGroceryItemByID(milk.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		if items, _ := store.GetGroceryItems(); len(items) != 0 {
+			t.Errorf("deleted milk is still in the inventory")
+		}
+
+		trash, err := store.GetDeletedGroceryItems()
+		if err != nil || len(trash) != 1 {
+			t.Fatalf("GetDeletedGroceryItems() = %d, %v, want milk", len(trash), err)
+		}
+		if trash[0].Category == nil || trash[0].Location == nil || trash[0].Location.Name != "Fridge" {
+			t.Errorf("trashed milk = %+v, want its category and location loaded", trash[0])
+		}
+
+		mustUpsert(t, store, GroceryItem{Name: "milk", Count: 2})
+		if _, err := store.RestoreGroceryItem(milk.ID); err == nil {
+			t.Error("restoring over a new milk succeeded, want a clash")
+		}
+
+		if err := store.PurgeGroceryItem(milk.ID); err != nil {
+			t.Fatal(err)
+		}
+		if events, _ := store.GetInventoryEvents(milk.ID); len(events) != 0 {
+			t.Errorf("purged milk kept %d events", len(events))
+		}
+
+		bread := mustUpsert(t, store, GroceryItem{Name: "bread", Count: 1})
+		if err := store.DeleteGroceryItemByID(bread.ID); err != nil {
+			t.Fatal(err)
+		}
+		if purged, err := store.PurgeDeletedGroceryItems(time.Now().Add(-time.Hour)); err != nil || purged != 0 {
+			t.Errorf("purging an hour back = %d, %v, want 0", purged, err)
+		}
+		if purged, err := store.PurgeDeletedGroceryItems(time.Now().Add(time.Hour)); err != nil || purged != 1 {
+			t.Errorf("purging everything = %d, %v, want 1", purged, err)
+		}
+		if err := store.PurgeGroceryItem(bread.ID); err == nil {
+			t.Error("purging bread twice succeeded")
+		}
+	})
+}
+
+func TestStoreParLevel(t *testing.T) {
+	eachStore(t, func(t *testing.T, store InventoryStore) {
+		yogurt := mustUpsert(t, store, GroceryItem{Name: "yogurt", Count: 1, MinCount: 4})
+		if quantity, ok := shortfall(t, store, yogurt.ID); !ok || quantity != 3 {
+			t.Errorf("shortfall = %v, %v, want 3 on the list", quantity, ok)
+		}
+
+		if _, err := store.AdjustGroceryItemCount(yogurt.ID, 3); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := shortfall(t, store, yogurt.ID); ok {
+			t.Error("yogurt is stocked to par but still on the list")
+		}
+
+		if _, err := store.AdjustGroceryItemCount(yogurt.ID, -2); err != nil {
+			t.Fatal(err)
+		}
+		if quantity, _ := shortfall(t, store, yogurt.ID); quantity != 2 {
+			t.Errorf("shortfall = %v, want 2", quantity)
+		}
+
+		if err := store.DeleteGroceryItemByID(yogurt.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := shortfall(t, store, yogurt.ID); ok {
+			t.Error("deleted yogurt is still on the list")
+		}
+
+		if _, err := store.RestoreGroceryItem(yogurt.ID); err != nil {
+			t.Fatal(err)
+		}
+		if quantity, _ := shortfall(t, store, yogurt.ID); quantity != 2 {
+			t.Errorf("shortfall after restore = %v, want 2", quantity)
+		}
+
+		if _, err := store.CreateShoppingListItem("bananas"); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.WipeGroceryItems(); err != nil {
+			t.Fatal(err)
+		}
+		entries, err := store.GetShoppingListItems()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 || entries[0].Name != "bananas" {
+			t.Errorf("list after wipe = %+v, want only the bananas added by hand", entries)
+		}
+	})
+}
+
+func TestStoreUndo(t *testing.T) {
+	eachStore(t, func(t *testing.T, store InventoryStore) {
+		milk := mustUpsert(t, store, GroceryItem{Name: "milk", Count: 1})
+
+		edited := milk
+		edited.Count = 3
+		if _, err := store.UpdateGroceryItem(edited); err != nil {
+			t.Fatal(err)
+		}
+
+		reverted, err := store.RevertGroceryItem(milk, EventUndo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reverted.Count != 1 {
+			t.Errorf("undone count = %v, want 1", reverted.Count)
+		}
+
+		events, err := store.GetInventoryEvents(milk.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if events[0].Kind != EventUndo || events[0].Delta != -2 {
+			t.Errorf("latest event = %s %v, want undo -2", events[0].Kind, events[0].Delta)
+		}
+
+		if _, err := store.RevertGroceryItem(milk, EventEdit); err == nil {
+			t.Error("reverting as an edit succeeded")
+		}
+
+		if err := store.DiscardGroceryItem(milk.ID); err != nil {
+			t.Fatal(err)
+		}
+		if trash, _ := store.GetDeletedGroceryItems(); len(trash) != 0 {
+			t.Errorf("discarded milk went to the trash")
+		}
+		if events, _ := store.GetInventoryEvents(milk.ID); len(events) != 0 {
+			t.Errorf("discarded milk kept %d events", len(events))
+		}
+		if err := store.DiscardGroceryItem(milk.ID); err == nil {
+			t.Error("discarding milk twice succeeded")
+		}
+	})
+}
+
+func TestStoreCategories(t *testing.T) {
+	eachStore(t, func(t *testing.T, store InventoryStore) {
+		snacks, err := store.CreateCategory(" Snacks ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if snacks.Name != "snacks" {
+			t.Errorf("category = %q, want snacks", snacks.Name)
+		}
+
+		if _, err := store.CreateCategory("SNACKS"); err == nil {
+			t.Error("adding SNACKS next to snacks succeeded")
+		}
+		if _, err := store.CreateCategory("  "); err == nil {
+			t.Error("adding a blank category succeeded")
+		}
+
+		categories, err := store.GetCategories()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(categories) != len(defaultCategories)+1 || categories[len(categories)-1].ID != snacks.ID {
+			t.Errorf("categories = %+v, want snacks after the defaults", categories)
+		}
+	})
+}
+
+func TestStoreLocations(t *testing.T) {
+	eachStore(t, func(t *testing.T, store InventoryStore) {
+		kitchen, err := store.CreateLocation("Kitchen", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fridge, err := store.CreateLocation("Fridge", &kitchen.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := store.CreateLocation("  fridge ", &kitchen.ID); err == nil {
+			t.Error("adding fridge next to Fridge succeeded")
+		}
+		if _, err := store.CreateLocation("Fridge", nil); err != nil {
+			t.Errorf("a Fridge at the top level clashed with the one in Kitchen: %v", err)
+		}
+
+		milk := mustUpsert(t, store, GroceryItem{Name: "milk", Count: 1, LocationID: &fridge.ID})
+		butter := mustUpsert(t, store, GroceryItem{Name: "butter", Count: 1, LocationID: &fridge.ID})
+		if err := store.DeleteGroceryItemByID(butter.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := store.DeleteLocation(kitchen.ID); err == nil {
+			t.Error("deleting Kitchen with Fridge inside succeeded")
+		}
+		if err := store.DeleteLocation(fridge.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		milk, err = store.GetGroceryItemByID(milk.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if milk.LocationID == nil || *milk.LocationID != kitchen.ID {
+			t.Errorf("milk is in %v, want it moved up to Kitchen", milk.LocationID)
+		}
+
+		events, err := store.GetInventoryEvents(milk.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if events[0].Kind != EventEdit || !strings.Contains(events[0].Note, "Fridge") {
+			t.Errorf("latest event = %s %q, want an edit for leaving Fridge", events[0].Kind, events[0].Note)
+		}
+
+		butter, err = store.RestoreGroceryItem(butter.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if butter.LocationID == nil || *butter.LocationID != kitchen.ID {
+			t.Errorf("butter from the trash is in %v, want it moved up to Kitchen", butter.LocationID)
+		}
+	})
+}
+
+func TestStoreSettings(t *testing.T) {
+	eachStore(t, func(t *testing.T, store InventoryStore) {
+		if err := store.SetSetting(SettingExpiryWindow, "14"); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.SetSetting(SettingExpiryWindow, "7"); err != nil {
+			t.Fatal(err)
+		}
+
+		values, err := store.GetSettings()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := SettingInt(values, SettingExpiryWindow, 3); got != 7 {
+			t.Errorf("expiry window = %d, want 7", got)
+		}
+		if got := SettingBool(values, SettingConfirmDelete, true); !got {
+			t.Error("confirm delete is off without being set")
+		}
+	})
+}
+
+func TestStoreMissingItems(t *testing.T) {
+	eachStore(t, func(t *testing.T, store InventoryStore) {
+		if _, err := store.GetGroceryItemByID(42); err == nil {
+			t.Error("GetGroceryItemByID(42) found an item in an empty store")
+		}
+		if _, err := store.GetGroceryItemByName("milk"); err == nil {
+			t.Error("GetGroceryItemByName(milk) found an item in an empty store")
+		}
+		if err := store.DeleteGroceryItemByID(42); err == nil {
+			t.Error("DeleteGroceryItemByID(42) succeeded in an empty store")
+		}
+		if _, err := store.RestoreGroceryItem(42); err == nil {
+			t.Error("RestoreGroceryItem(42) succeeded in an empty store")
+		}
+	})
+}
@@ -0,0 +1,209 @@
+// Package ingredient parses free-form ingredient lines such as "3 lbs flour"
+// or "1 1/2 cups of sugar" into a name, quantity and unit.
+package ingredient
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/lundjrl/go-bubble-tea-playground/shared/units"
+)
+
+type Line struct {
+	Name string
+	// Quantity is zero when the line doesn't start with one.
+	Quantity float64
+	// Unit is the canonical unit name, or empty when none was given.
+	Unit string
+}
+
+var vulgarFractions = map[rune]string{
+	'½': "1/2",
+	'⅓': "1/3",
+	'⅔': "2/3",
+	'¼': "1/4",
+	'¾': "3/4",
+	'⅕': "1/5",
+	'⅖': "2/5",
+	'⅗': "3/5",
+	'⅘': "4/5",
+	'⅙': "1/6",
+	'⅚': "5/6",
+	'⅛': "1/8",
+	'⅜': "3/8",
+	'⅝': "5/8",
+	'⅞': "7/8",
+}
+
+// Parse splits an ingredient line into its quantity, unit and name. A unit is
+// only recognised after a quantity, so "can opener" stays a name.
+func Parse(line string) (Line, error) {
+	tokens := strings.Fields(expandFractions(strings.ToLower(line)))
+
+	var parsed Line
+	var err error
+
+	parsed.Quantity, tokens, err = parseQuantity(tokens)
+	if err != nil {
+		return parsed, err
+	}
+
+	if parsed.Quantity > 0 && len(tokens) > 0 {
+		if unit, ok := lookupUnit(tokens[0]); ok {
+			parsed.Unit = unit
+			tokens = tokens[1:]
+
+			if len(tokens) > 0 && tokens[0] == "of" {
+				tokens = tokens[1:]
+			}
+		}
+	}
+
+	parsed.Name = strings.Join(tokens, " ")
+
+	if parsed.Name == "" {
+		return parsed, errors.New("Please type a grocery item.")
+	}
+
+	return parsed, nil
+}
+
+// ParseAmount reads a bare quantity with an optional unit, such as "3",
+// "1 1/2 cups" or "500g".
+func ParseAmount(text string) (float64, string, error) {
+	tokens := strings.Fields(expandFractions(strings.ToLower(text)))
+
+	quantity, tokens, err := parseQuantity(tokens)
+	if err != nil {
+		return 0, "", err
+	}
+
+	if quantity <= 0 {
+		return 0, "", errors.New("Please type an amount, like 3 or 500g.")
+	}
+
+	unit := ""
+	if len(tokens) > 0 {
+		var ok bool
+		if unit, ok = lookupUnit(tokens[0]); !ok {
+			return 0, "", errors.New("Please type an amount, like 3 or 500g.")
+		}
+		tokens = tokens[1:]
+	}
+
+	if len(tokens) > 0 {
+		return 0, "", errors.New("Please type an amount, like 3 or 500g.")
+	}
+
+	return quantity, unit, nil
+}
+
+// expandFractions rewrites unicode fractions as "n/d", splitting them from a
+// leading whole number so "1½" reads as "1 1/2".
+func expandFractions(line string) string {
+	var b strings.Builder
+
+	for _, r := range line {
+		fraction, ok := vulgarFractions[r]
+		if !ok {
+			b.WriteRune(r)
+			continue
+		}
+		b.WriteString(" " + fraction + " ")
+	}
+
+	return b.String()
+}
+
+// parseQuantity consumes a leading quantity such as "2", "1.5", "3/4",
+// "1 1/2", "500g" or "a" and returns the remaining tokens.
+func parseQuantity(tokens []string) (float64, []string, error) {
+	if len(tokens) == 0 {
+		return 0, tokens, nil
+	}
+
+	if (tokens[0] == "a" || tokens[0] == "an") && len(tokens) > 1 {
+		if _, ok := lookupUnit(tokens[1]); ok {
+			return 1, tokens[1:], nil
+		}
+		return 0, tokens, nil
+	}
+
+	number, unit := splitNumber(tokens[0])
+	if number == "" {
+		return 0, tokens, nil
+	}
+
+	if unit != "" {
+		if _, ok := lookupUnit(unit); !ok {
+			return 0, tokens, nil
+		}
+	}
+
+	quantity, err := parseNumber(number)
+	if err != nil {
+		return 0, tokens, err
+	}
+
+	rest := tokens[1:]
+	if unit != "" {
+		return quantity, append([]string{unit}, rest...), nil
+	}
+
+	// A whole number followed by a fraction, as in "1 1/2".
+	if len(rest) > 0 && strings.Contains(rest[0], "/") && !strings.Contains(number, "/") && !strings.Contains(number, ".") {
+		if fraction, err := parseNumber(rest[0]); err == nil {
+			return quantity + fraction, rest[1:], nil
+		}
+	}
+
+	return quantity, rest, nil
+}
+
+// splitNumber splits a token like "500g" into "500" and "g".
+func splitNumber(token string) (string, string) {
+	end := strings.IndexFunc(token, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '.' && r != '/'
+	})
+
+	if end == -1 {
+		return token, ""
+	}
+
+	return token[:end], token[end:]
+}
+
+func parseNumber(number string) (float64, error) {
+	numerator, denominator, isFraction := strings.Cut(number, "/")
+
+	if !isFraction {
+		value, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			return 0, errors.New("That quantity doesn't look like a number.")
+		}
+		return value, nil
+	}
+
+	n, err := strconv.ParseFloat(numerator, 64)
+	if err != nil {
+		return 0, errors.New("That quantity doesn't look like a number.")
+	}
+
+	d, err := strconv.ParseFloat(denominator, 64)
+	if err != nil || d == 0 {
+		return 0, errors.New("That quantity doesn't look like a number.")
+	}
+
+	return n / d, nil
+}
+
+func lookupUnit(word string) (string, bool) {
+	if word == "" {
+		return "", false
+	}
+
+	unit, ok := units.Lookup(word)
+	return unit.Name, ok
+}
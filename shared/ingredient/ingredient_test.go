@@ -0,0 +1,79 @@
+package ingredient
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		line string
+		want Line
+	}{
+		{"milk", Line{Name: "milk"}},
+		{"3 eggs", Line{Name: "eggs", Quantity: 3}},
+		{"3 lbs Flour", Line{Name: "flour", Quantity: 3, Unit: "lb"}},
+		{"500g butter", Line{Name: "butter", Quantity: 500, Unit: "g"}},
+		{"1 1/2 cups of sugar", Line{Name: "sugar", Quantity: 1.5, Unit: "cup"}},
+		{"1½ cups rice", Line{Name: "rice", Quantity: 1.5, Unit: "cup"}},
+		{"¾ tsp salt", Line{Name: "salt", Quantity: 0.75, Unit: "tsp"}},
+		{"0.5 l oat milk", Line{Name: "oat milk", Quantity: 0.5, Unit: "l"}},
+		{"a dozen eggs", Line{Name: "eggs", Quantity: 1, Unit: "dozen"}},
+		{"an apple", Line{Name: "an apple"}},
+		{"can opener", Line{Name: "can opener"}},
+		{"2 cans tomatoes", Line{Name: "tomatoes", Quantity: 2, Unit: "can"}},
+		{"7up", Line{Name: "7up"}},
+	}
+
+	for _, test := range tests {
+		got, err := Parse(test.line)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", test.line, err)
+			continue
+		}
+		if got.Name != test.want.Name || got.Unit != test.want.Unit || math.Abs(got.Quantity-test.want.Quantity) > 1e-9 {
+			t.Errorf("Parse(%q) = %+v, want %+v", test.line, got, test.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, line := range []string{"", "   ", "3", "2 cups", "1/0 cup flour"} {
+		if got, err := Parse(line); err == nil {
+			t.Errorf("Parse(%q) = %+v, want an error", line, got)
+		}
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		text     string
+		quantity float64
+		unit     string
+	}{
+		{"3", 3, ""},
+		{"500g", 500, "g"},
+		{"1 1/2 cups", 1.5, "cup"},
+		{"½ gallon", 0.5, "gallon"},
+		{"2 Dozen", 2, "dozen"},
+	}
+
+	for _, test := range tests {
+		quantity, unit, err := ParseAmount(test.text)
+		if err != nil {
+			t.Errorf("ParseAmount(%q) failed: %v", test.text, err)
+			continue
+		}
+		if unit != test.unit || math.Abs(quantity-test.quantity) > 1e-9 {
+			t.Errorf("ParseAmount(%q) = %v %q, want %v %q", test.text, quantity, unit, test.quantity, test.unit)
+		}
+	}
+}
+
+func TestParseAmountErrors(t *testing.T) {
+	for _, text := range []string{"", "0", "-2", "cups", "3 handfuls", "2 cups flour", "abc"} {
+		if quantity, unit, err := ParseAmount(text); err == nil {
+			t.Errorf("ParseAmount(%q) = %v %q, want an error", text, quantity, unit)
+		}
+	}
+}
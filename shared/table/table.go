@@ -1,100 +0,0 @@
-package table
-
-import (
-	"fmt"
-	"os"
-
-	"github.com/charmbracelet/bubbles/table"
-	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
-	db "github.com/lundjrl/go-bubble-tea-playground/shared/database"
-)
-
-var baseStyle = lipgloss.NewStyle().
-	BorderStyle(lipgloss.NormalBorder()).
-	BorderForeground(lipgloss.Color("240"))
-
-type model struct {
-	table table.Model
-}
-
-func (m model) Init() tea.Cmd { return nil }
-
-func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	var cmd tea.Cmd
-	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		switch msg.String() {
-		case "esc":
-			if m.table.Focused() {
-				m.table.Blur()
-			} else {
-				m.table.Focus()
-			}
-		case "q", "ctrl+c":
-			return m, tea.Quit
-		case "enter":
-			// TODO: write function that opens detailed view of item in second window.
-			return m, tea.Batch(
-				tea.Printf(m.table.SelectedRow()[1], " was selected."),
-			)
-		}
-	}
-	m.table, cmd = m.table.Update(msg)
-	return m, cmd
-}
-
-func (m model) View() string {
-	return baseStyle.Render(m.table.View()) + "\n"
-}
-
-func Main() (tea.Model, error) {
-	columns := []table.Column{
-		{Title: "ID", Width: 4},
-		{Title: "Name", Width: 10},
-		{Title: "Count", Width: 10},
-	}
-
-	var items []db.GroceryItem
-	result := db.DBConn.Find(&items)
-
-	if result.Error != nil {
-		panic(result.Error)
-	}
-
-	tableRows := []table.Row{}
-
-	for _, item := range items {
-		row := []string{fmt.Sprint(item.ID), item.Name, fmt.Sprint(item.Count)}
-		tableRows = append(tableRows, row)
-	}
-
-	t := table.New(
-		table.WithColumns(columns),
-		table.WithRows(tableRows),
-		table.WithFocused(true),
-		table.WithHeight(7),
-	)
-
-	s := table.DefaultStyles()
-	s.Header = s.Header.
-		BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(lipgloss.Color("240")).
-		BorderBottom(true).
-		Bold(false)
-	s.Selected = s.Selected.
-		Foreground(lipgloss.Color("229")).
-		Background(lipgloss.Color("57")).
-		Bold(false)
-	t.SetStyles(s)
-
-	m := model{t}
-	model, err := tea.NewProgram(m).Run()
-
-	if err != nil {
-		fmt.Println("Error running program:", err)
-		os.Exit(1)
-	}
-
-	return model, err
-}
@@ -0,0 +1,162 @@
+// Package units knows the units of measure chef understands and converts
+// quantities between compatible ones.
+package units
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Dimension int
+
+const (
+	// Count covers plain counts; an empty unit is counted in "each".
+	Count Dimension = iota
+	Mass
+	Volume
+	// Package units (cans, bags, ...) only combine with the same unit.
+	Package
+)
+
+func (d Dimension) String() string {
+	switch d {
+	case Count:
+		return "count"
+	case Mass:
+		return "mass"
+	case Volume:
+		return "volume"
+	case Package:
+		return "package"
+	}
+	return "unknown"
+}
+
+type Unit struct {
+	Name      string
+	Dimension Dimension
+	// Factor is the size of one unit in its dimension's base unit: each, grams
+	// or millilitres.
+	Factor float64
+}
+
+var known = []Unit{
+	{"each", Count, 1},
+	{"pair", Count, 2},
+	{"dozen", Count, 12},
+
+	{"g", Mass, 1},
+	{"kg", Mass, 1000},
+	{"oz", Mass, 28.349523125},
+	{"lb", Mass, 453.59237},
+
+	{"ml", Volume, 1},
+	{"l", Volume, 1000},
+	{"tsp", Volume, 4.92892159375},
+	{"tbsp", Volume, 14.78676478125},
+	{"cup", Volume, 236.5882365},
+	{"pint", Volume, 473.176473},
+	{"quart", Volume, 946.352946},
+	{"gallon", Volume, 3785.411784},
+
+	{"can", Package, 1},
+	{"bag", Package, 1},
+	{"box", Package, 1},
+	{"bottle", Package, 1},
+	{"jar", Package, 1},
+	{"pack", Package, 1},
+	{"bunch", Package, 1},
+	{"loaf", Package, 1},
+}
+
+// aliases lists the other spellings accepted for each canonical unit.
+var aliases = map[string][]string{
+	"each":   {"ea", "pc", "pcs", "piece", "pieces"},
+	"pair":   {"pairs"},
+	"dozen":  {"doz"},
+	"g":      {"gr", "gram", "grams"},
+	"kg":     {"kgs", "kilo", "kilos", "kilogram", "kilograms"},
+	"oz":     {"ounce", "ounces"},
+	"lb":     {"lbs", "pound", "pounds"},
+	"ml":     {"milliliter", "milliliters", "millilitre", "millilitres"},
+	"l":      {"liter", "liters", "litre", "litres"},
+	"tsp":    {"teaspoon", "teaspoons"},
+	"tbsp":   {"tbs", "tablespoon", "tablespoons"},
+	"cup":    {"cups"},
+	"pint":   {"pt", "pints"},
+	"quart":  {"qt", "quarts"},
+	"gallon": {"gal", "gallons"},
+	"can":    {"cans"},
+	"bag":    {"bags"},
+	"box":    {"boxes"},
+	"bottle": {"bottles"},
+	"jar":    {"jars"},
+	"pack":   {"packs", "package", "packages"},
+	"bunch":  {"bunches"},
+	"loaf":   {"loaves"},
+}
+
+var byName = map[string]Unit{}
+
+func init() {
+	for _, unit := range known {
+		byName[unit.Name] = unit
+	}
+
+	for name, spellings := range aliases {
+		for _, spelling := range spellings {
+			byName[spelling] = byName[name]
+		}
+	}
+}
+
+// Lookup finds a unit by any of its spellings. The empty unit is "each".
+func Lookup(name string) (Unit, bool) {
+	name = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+
+	if name == "" {
+		return byName["each"], true
+	}
+
+	unit, ok := byName[name]
+	return unit, ok
+}
+
+// Canonical returns the canonical spelling of a unit. Units chef doesn't know
+// are returned lowercased so they can still be stored.
+func Canonical(name string) string {
+	if unit, ok := Lookup(name); ok && strings.TrimSpace(name) != "" {
+		return unit.Name
+	}
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+// Convert expresses quantity, measured in from, in the unit to.
+func Convert(quantity float64, from string, to string) (float64, error) {
+	if Canonical(from) == Canonical(to) {
+		return quantity, nil
+	}
+
+	fromUnit, ok := Lookup(from)
+	if !ok {
+		return 0, fmt.Errorf("%q isn't a unit chef can convert.", from)
+	}
+
+	toUnit, ok := Lookup(to)
+	if !ok {
+		return 0, fmt.Errorf("%q isn't a unit chef can convert.", to)
+	}
+
+	if fromUnit.Dimension != toUnit.Dimension || fromUnit.Dimension == Package {
+		return 0, fmt.Errorf("Can't convert %s (%s) to %s (%s).", displayName(from), fromUnit.Dimension, displayName(to), toUnit.Dimension)
+	}
+
+	return quantity * fromUnit.Factor / toUnit.Factor, nil
+}
+
+func displayName(name string) string {
+	if strings.TrimSpace(name) == "" {
+		return "each"
+	}
+	return Canonical(name)
+}
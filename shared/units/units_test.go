@@ -0,0 +1,81 @@
+package units
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCanonical(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"", ""},
+		{"g", "g"},
+		{"Grams", "g"},
+		{" lbs. ", "lb"},
+		{"Litre", "l"},
+		{"tablespoons", "tbsp"},
+		{"loaves", "loaf"},
+		{"pcs", "each"},
+		{"Sprigs  Of", "sprigs of"},
+	}
+
+	for _, test := range tests {
+		if got := Canonical(test.name); got != test.want {
+			t.Errorf("Canonical(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		quantity float64
+		from     string
+		to       string
+		want     float64
+	}{
+		{1, "kg", "g", 1000},
+		{500, "g", "kg", 0.5},
+		{1, "lb", "oz", 16},
+		{2, "cups", "ml", 473.176473},
+		{1, "gallon", "quart", 4},
+		{3, "tsp", "tbsp", 1},
+		{1, "dozen", "", 12},
+		{6, "each", "dozen", 0.5},
+		{2, "pair", "each", 4},
+		{3, "cans", "can", 3},
+		{2, "sprigs", "sprigs", 2},
+	}
+
+	for _, test := range tests {
+		got, err := Convert(test.quantity, test.from, test.to)
+		if err != nil {
+			t.Errorf("Convert(%v, %q, %q) failed: %v", test.quantity, test.from, test.to, err)
+			continue
+		}
+		if math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("Convert(%v, %q, %q) = %v, want %v", test.quantity, test.from, test.to, got, test.want)
+		}
+	}
+}
+
+func TestConvertIncompatible(t *testing.T) {
+	tests := []struct {
+		from string
+		to   string
+	}{
+		{"g", "ml"},
+		{"cup", ""},
+		{"can", "jar"},
+		{"can", "each"},
+		{"sprigs", "g"},
+		{"g", "handfuls"},
+	}
+
+	for _, test := range tests {
+		if _, err := Convert(1, test.from, test.to); err == nil {
+			t.Errorf("Convert(1, %q, %q) succeeded, want an error", test.from, test.to)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type Theme struct {
+	blue     lipgloss.Color
+	pink     lipgloss.Color
+	yellow   lipgloss.Color
+	lavender lipgloss.Color
+	red      lipgloss.Color
+	bg       lipgloss.Color
+	fg       lipgloss.Color
+}
+
+// Catppuccin flavours, in the order they're cycled on the settings tab.
+var themeNames = []string{"mocha", "frappe", "latte"}
+
+var themes = map[string]Theme{
+	"mocha": {
+		blue:     lipgloss.Color("#89b4fa"),
+		pink:     lipgloss.Color("#f5c2e7"),
+		yellow:   lipgloss.Color("#f9e2af"),
+		lavender: lipgloss.Color("#b4befe"),
+		red:      lipgloss.Color("#f38ba8"),
+		bg:       lipgloss.Color("#11111b"),
+		fg:       lipgloss.Color("#cdd6f4")},
+	"frappe": {
+		blue:     lipgloss.Color("#8caaee"),
+		pink:     lipgloss.Color("#f4b8e4"),
+		yellow:   lipgloss.Color("#e5c890"),
+		lavender: lipgloss.Color("#babbf1"),
+		red:      lipgloss.Color("#e78284"),
+		bg:       lipgloss.Color("#232634"),
+		fg:       lipgloss.Color("#c6d0f5")},
+	"latte": {
+		blue:     lipgloss.Color("#1e66f5"),
+		pink:     lipgloss.Color("#ea76cb"),
+		yellow:   lipgloss.Color("#df8e1d"),
+		lavender: lipgloss.Color("#7287fd"),
+		red:      lipgloss.Color("#d20f39"),
+		bg:       lipgloss.Color("#dce0e8"),
+		fg:       lipgloss.Color("#4c4f69")},
+}
+
+var theme = themes["mocha"]
+
+// setTheme swaps the active theme and recolours the shared styles. Unknown
+// names are ignored so a stale setting can't break the UI.
+func setTheme(name string) {
+	t, ok := themes[name]
+	if !ok {
+		return
+	}
+
+	theme = t
+
+	focusedModelStyle = focusedModelStyle.BorderForeground(theme.pink)
+	tipContainerStyle = tipContainerStyle.Foreground(theme.fg).BorderForeground(theme.yellow)
+	focusedTableStyle = focusedTableStyle.BorderForeground(theme.pink)
+	highlight = highlight.Foreground(theme.pink)
+	tab = tab.BorderForeground(theme.pink)
+	activeTab = activeTab.BorderForeground(theme.pink).Background(theme.blue)
+	tabGap = tabGap.BorderForeground(theme.pink)
+
+	listItemStyle = listItemStyle.Foreground(theme.fg)
+	listCursorStyle = listCursorStyle.Foreground(theme.pink)
+	listCheckedStyle = listCheckedStyle.Foreground(theme.lavender)
+	focusedListInputStyle = focusedListInputStyle.BorderForeground(theme.pink)
+	detailStyle = detailStyle.BorderForeground(theme.pink)
+	detailLabelStyle = detailLabelStyle.Foreground(theme.lavender)
+}
+
+// tableStyles builds the inventory table styles from the active theme.
+func tableStyles() table.Styles {
+	s := table.DefaultStyles()
+	s.Header = s.Header.
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(theme.fg).
+		BorderBottom(true).
+		Bold(false)
+	s.Selected = s.Selected.
+		Foreground(theme.bg).
+		Background(theme.yellow).
+		Bold(true)
+
+	return s
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func reloadTrash(m mainModel) mainModel {
+	trash, err := m.store.GetDeletedGroceryItems()
+	if err != nil {
+		m.err = err
+		return m
+	}
+
+	m.trash = trash
+	m.trashCursor = min(m.trashCursor, max(0, len(trash)-1))
+
+	return m
+}
+
+// purgeExpiredTrash permanently removes items that have been in the trash
+// longer than the retention setting. A retention of 0 keeps them forever.
+// It only runs when chef opens, never while the setting is being changed.
+func purgeExpiredTrash(m mainModel) (mainModel, error) {
+	if m.settings.trashDays <= 0 {
+		return m, nil
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -m.settings.trashDays)
+	purged, err := m.store.PurgeDeletedGroceryItems(cutoff)
+	if err != nil {
+		return m, err
+	}
+	if purged > 0 {
+		m = clearUndo(m)
+	}
+
+	return reloadTrash(m), nil
+}
+
+func getTrashItemsUI(m mainModel) string {
+	if len(m.trash) == 0 {
+		return listItemStyle.Foreground(theme.lavender).Render("The trash is empty.")
+	}
+
+	lines := []string{}
+
+	for i, item := range m.trash {
+		line := fmt.Sprintf("%-20s %-10s deleted %s", item.Name, formatQuantity(item.Count, item.Unit), item.DeletedAt.Time.Local().Format("Jan 02 15:04"))
+
+		if i == m.trashCursor {
+			lines = append(lines, listCursorStyle.Render("> "+line))
+		} else {
+			lines = append(lines, listItemStyle.Render("  "+line))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func getTrashUI(m mainModel) string {
+	if m.currentTab != trashTab {
+		return ""
+	}
+
+	titleStyle := lipgloss.NewStyle().
+		PaddingTop(2).
+		MarginLeft(6).
+		Height(2).
+		Bold(true).Foreground(theme.blue).
+		Render("Trash")
+
+	description := "Deleted items are kept until you empty them"
+	if m.settings.trashDays > 0 {
+		description = fmt.Sprintf("Deleted items are kept for %d days", m.settings.trashDays)
+	}
+
+	descriptionStyle := lipgloss.NewStyle().
+		Bold(true).PaddingTop(3).
+		Foreground(theme.lavender).
+		MarginLeft(2).
+		Render(description)
+
+	line := lipgloss.NewStyle().
+		BorderForeground(theme.pink).
+		BorderTop(true).
+		BorderStyle(lipgloss.NormalBorder()).
+		PaddingTop(-1).
+		Width(50).
+		MarginLeft(6).Render()
+
+	spacer := lipgloss.NewStyle().
+		Height(1).Render(" ")
+
+	trashHelperText := tipContainerStyle.MarginLeft(6).Width(60).Padding(1).Render("r: restore • D: delete forever • q: exit")
+	if m.confirmingPurge && len(m.trash) > 0 {
+		prompt := fmt.Sprintf("Delete %s forever? This can't be undone. y: yes • n: no", m.trash[m.trashCursor].Name)
+		trashHelperText = tipContainerStyle.MarginLeft(6).Width(60).Padding(1).BorderForeground(theme.pink).Render(prompt)
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Top, lipgloss.JoinHorizontal(lipgloss.Center, titleStyle, descriptionStyle), line, spacer, getTrashItemsUI(m), trashHelperText, spacer)
+}
+
+// updateTrash handles key presses while the trash tab is open.
+func updateTrash(m mainModel, msg tea.KeyMsg) (mainModel, tea.Cmd) {
+	if m.confirmingPurge {
+		m.confirmingPurge = false
+		if msg.String() == "y" && len(m.trash) > 0 {
+			item := m.trash[m.trashCursor]
+			if err := m.store.PurgeGroceryItem(item.ID); err != nil {
+				m.err = err
+				return m, nil
+			}
+			m = clearUndo(reloadTrash(m))
+			m.status = fmt.Sprintf("%s is gone for good.", item.Name)
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "up", "k":
+		if m.trashCursor > 0 {
+			m.trashCursor--
+		}
+	case "down", "j":
+		if m.trashCursor < len(m.trash)-1 {
+			m.trashCursor++
+		}
+	case "r", "enter":
+		if len(m.trash) == 0 {
+			break
+		}
+		item, err := m.store.RestoreGroceryItem(m.trash[m.trashCursor].ID)
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		m = clearUndo(reloadInventory(m, item.ID))
+		m.status = fmt.Sprintf("%s is back in the inventory.", item.Name)
+	case "D", "delete":
+		if len(m.trash) > 0 {
+			m.confirmingPurge = true
+		}
+	}
+
+	return m, nil
+}
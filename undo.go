@@ -0,0 +1,133 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	db "github.com/lundjrl/go-bubble-tea-playground/shared/database"
+)
+
+// undoLimit caps how many steps u can walk back.
+const undoLimit = 50
+
+type undoKind int
+
+const (
+	undoCreate undoKind = iota
+	undoUpdate
+	undoDelete
+)
+
+// undoStep is one inventory change, kept with the item as it was before and
+// after so it can be replayed in either direction.
+type undoStep struct {
+	kind        undoKind
+	description string
+	before      db.GroceryItem
+	after       db.GroceryItem
+}
+
+// pushUndo records a change that was just made. Any steps that were undone
+// can't be redone after a new change.
+func pushUndo(m mainModel, step undoStep) mainModel {
+	m.undoStack = append(m.undoStack, step)
+	if len(m.undoStack) > undoLimit {
+		m.undoStack = m.undoStack[len(m.undoStack)-undoLimit:]
+	}
+	m.redoStack = nil
+
+	return m
+}
+
+// clearUndo forgets every step. Wiping the inventory or changing the trash
+// can remove or bring back the items the steps refer to, so they can't be
+// replayed safely afterwards.
+func clearUndo(m mainModel) mainModel {
+	m.undoStack = nil
+	m.redoStack = nil
+
+	return m
+}
+
+// applyStep writes a step to the store, backwards when undoing. Undoing an
+// add removes the item for good rather than sending it to the trash, and
+// edits are recorded in the history as undone or redone.
+func applyStep(m mainModel, step undoStep, undo bool) (db.GroceryItem, error) {
+	switch step.kind {
+	case undoCreate:
+		if undo {
+			return step.after, m.store.DiscardGroceryItem(step.after.ID)
+		}
+		item, _, err := m.store.UpsertGroceryItem(step.after)
+		return item, err
+	case undoDelete:
+		if undo {
+			return m.store.RestoreGroceryItem(step.before.ID)
+		}
+		return step.before, m.store.DeleteGroceryItemByID(step.before.ID)
+	}
+
+	if undo {
+		return m.store.RevertGroceryItem(step.before, db.EventUndo)
+	}
+	return m.store.RevertGroceryItem(step.after, db.EventRedo)
+}
+
+// renumberSteps points the steps for item from at item to, after redoing an
+// add has saved the item again under a new ID.
+func renumberSteps(steps []undoStep, from uint, to uint) {
+	for i := range steps {
+		if steps[i].before.ID == from {
+			steps[i].before.ID = to
+		}
+		if steps[i].after.ID == from {
+			steps[i].after.ID = to
+		}
+	}
+}
+
+func undo(m mainModel) mainModel {
+	if len(m.undoStack) == 0 {
+		m.err = errors.New("Nothing to undo.")
+		return m
+	}
+
+	step := m.undoStack[len(m.undoStack)-1]
+
+	item, err := applyStep(m, step, true)
+	if err != nil {
+		m.err = err
+		return m
+	}
+
+	m.undoStack = m.undoStack[:len(m.undoStack)-1]
+	m.redoStack = append(m.redoStack, step)
+	m.status = fmt.Sprintf("Undid %s.", step.description)
+
+	return reloadInventory(m, item.ID)
+}
+
+func redo(m mainModel) mainModel {
+	if len(m.redoStack) == 0 {
+		m.err = errors.New("Nothing to redo.")
+		return m
+	}
+
+	step := m.redoStack[len(m.redoStack)-1]
+
+	item, err := applyStep(m, step, false)
+	if err != nil {
+		m.err = err
+		return m
+	}
+
+	m.redoStack = m.redoStack[:len(m.redoStack)-1]
+	if step.kind == undoCreate && item.ID != step.after.ID {
+		renumberSteps(m.redoStack, step.after.ID, item.ID)
+		step.after = item
+	}
+	m.undoStack = append(m.undoStack, step)
+	m.status = fmt.Sprintf("Redid %s.", step.description)
+
+	return reloadInventory(m, item.ID)
+}